@@ -0,0 +1,161 @@
+package snowflake
+
+import (
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func newBenchmarkAlgorithm(tb testing.TB, options ...Option) *Algorithm {
+	tb.Helper()
+
+	// twitter layout每毫秒4096个sequence, 减少等待下一毫秒对结果的影响
+	a, err := New(1, append([]Option{WithLayout(LayoutTwitter)}, options...)...)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return a
+}
+
+func BenchmarkNextID(b *testing.B) {
+	a := newBenchmarkAlgorithm(b)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := a.NextID(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkNextIDContention(b *testing.B) {
+	for _, goroutines := range []int{8, 64} {
+		b.Run(strconv.Itoa(goroutines)+"-goroutines", func(b *testing.B) {
+			a := newBenchmarkAlgorithm(b)
+
+			b.ReportAllocs()
+			runGoroutines(b, goroutines, func() {
+				if _, err := a.NextID(); err != nil {
+					b.Error(err)
+				}
+			})
+		})
+	}
+}
+
+func BenchmarkNextIDBatch(b *testing.B) {
+	const batch = 1024
+	a := newBenchmarkAlgorithm(b)
+	ids := make([]uint64, batch)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		for j := range ids {
+			id, err := a.NextID()
+			if err != nil {
+				b.Fatal(err)
+			}
+			ids[j] = id
+		}
+	}
+}
+
+// TestParallelUniqueness generates tens of millions of ids concurrently, run it with -race.
+func TestParallelUniqueness(t *testing.T) {
+	const goroutines = 64
+	total := 10_000_000
+	if testing.Short() {
+		total = 1_000_000
+	}
+
+	a := newBenchmarkAlgorithm(t)
+	perGoroutine := total / goroutines
+	results := make([][]uint64, goroutines)
+
+	var wg sync.WaitGroup
+	for g := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			ids := make([]uint64, perGoroutine)
+			for i := range ids {
+				id, err := a.NextID()
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				ids[i] = id
+			}
+			results[g] = ids
+		}()
+	}
+	wg.Wait()
+
+	all := slices.Concat(results...)
+	if len(all) != perGoroutine*goroutines {
+		t.Fatalf("generated %d ids, want %d", len(all), perGoroutine*goroutines)
+	}
+	slices.Sort(all)
+	for i := 1; i < len(all); i++ {
+		if all[i] == all[i-1] {
+			t.Fatalf("duplicate id %d", all[i])
+		}
+	}
+}
+
+// runGoroutines split b.N calls of fn between the goroutines.
+func runGoroutines(b *testing.B, goroutines int, fn func()) {
+	var wg sync.WaitGroup
+	b.ResetTimer()
+	for g := 0; g < goroutines; g++ {
+		n := b.N / goroutines
+		if g < b.N%goroutines {
+			n++
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < n; i++ {
+				fn()
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// benchmarkResolver resolve the sequences of r from all the parallel goroutines, the tick is
+// moved on by the goroutine which finds it used up.
+func benchmarkResolver(b *testing.B, r SequenceResolver) {
+	const limit = 1 << 20
+	var tick atomic.Int64
+
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			for {
+				ms := tick.Load()
+				seq, err := r.Resolve(ms, 0, limit)
+				if err != nil {
+					b.Error(err)
+					return
+				}
+				if seq < limit {
+					break
+				}
+				tick.CompareAndSwap(ms, ms+1)
+			}
+		}
+	})
+}
+
+func BenchmarkAtomicResolver(b *testing.B) {
+	benchmarkResolver(b, newAtomicSequenceResolver(new(uint64), 21))
+}
+
+func BenchmarkMutexResolver(b *testing.B) {
+	benchmarkResolver(b, NewMutexSequenceResolver())
+}
@@ -0,0 +1,206 @@
+// Command snowflake generates and decodes snowflake ids, e.g. to debug the ids pulled from logs.
+//
+// Usage:
+//
+//	snowflake gen -n 100 --node 3 [--epoch 1288834974657] [--node-bits 3] [--sequence-bits 7]
+//	snowflake parse [--epoch 1288834974657] [--node-bits 3] [--sequence-bits 7] <id>...
+//	snowflake migrate [--from-epoch ms] [--from-node-bits n] [--from-sequence-bits n]
+//		[--to-epoch ms] [--to-node-bits n] [--to-sequence-bits n] [<id>...]
+//
+// migrate re-encodes the ids, given as arguments or one per line on stdin, from the old layout
+// into the new one, it prints "<old id> <new id>" per id and reports the ids which cannot be
+// represented in the new layout.
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/hdget/snowflake"
+)
+
+const usage = `usage:
+  snowflake gen -n 100 --node 3 [--epoch ms] [--node-bits n] [--sequence-bits n]
+  snowflake parse [--epoch ms] [--node-bits n] [--sequence-bits n] <id>...
+  snowflake migrate [--from-epoch ms] [--from-node-bits n] [--from-sequence-bits n]
+                    [--to-epoch ms] [--to-node-bits n] [--to-sequence-bits n] [<id>...]
+`
+
+// layoutFlags are the flags describing the layout, shared by gen, parse and migrate.
+type layoutFlags struct {
+	epoch        *int64
+	nodeBits     *uint
+	sequenceBits *uint
+}
+
+// newLayoutFlags define the layout flags with the name prefix, e.g. "from-" for migrate.
+func newLayoutFlags(fs *flag.FlagSet, prefix string) layoutFlags {
+	return layoutFlags{
+		epoch:        fs.Int64(prefix+"epoch", 0, "epoch in unix milliseconds, default the twitter epoch"),
+		nodeBits:     fs.Uint(prefix+"node-bits", 0, "node bits, default 3"),
+		sequenceBits: fs.Uint(prefix+"sequence-bits", 0, "sequence bits, default 7"),
+	}
+}
+
+func (f layoutFlags) options() []snowflake.Option {
+	var options []snowflake.Option
+	if *f.epoch != 0 {
+		options = append(options, snowflake.WithEpochMillis(*f.epoch))
+	}
+	if *f.nodeBits != 0 {
+		options = append(options, snowflake.WithNodeBits(uint8(*f.nodeBits)))
+	}
+	if *f.sequenceBits != 0 {
+		options = append(options, snowflake.WithSequenceBits(uint8(*f.sequenceBits)))
+	}
+	return options
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "gen":
+		err = gen(os.Args[2:])
+	case "parse":
+		err = parse(os.Args[2:])
+	case "migrate":
+		err = migrate(os.Args[2:])
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "snowflake: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func gen(args []string) error {
+	fs := flag.NewFlagSet("gen", flag.ExitOnError)
+	n := fs.Int("n", 1, "number of ids")
+	node := fs.Uint64("node", 0, "node id, required")
+	layout := newLayoutFlags(fs, "")
+	_ = fs.Parse(args)
+
+	a, err := snowflake.New(*node, layout.options()...)
+	if err != nil {
+		return err
+	}
+
+	for i := 0; i < *n; i++ {
+		id, err := a.NextID()
+		if err != nil {
+			return err
+		}
+		fmt.Println(id)
+	}
+	return nil
+}
+
+func parse(args []string) error {
+	fs := flag.NewFlagSet("parse", flag.ExitOnError)
+	layout := newLayoutFlags(fs, "")
+	_ = fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		return errors.New("no id to parse")
+	}
+
+	// 解析不需要真实的node id
+	a, err := snowflake.New(1, layout.options()...)
+	if err != nil {
+		return err
+	}
+
+	for i, arg := range fs.Args() {
+		id, err := snowflake.ParseString(arg)
+		if err != nil {
+			return err
+		}
+
+		if i > 0 {
+			fmt.Println()
+		}
+		parsed := a.Parse(id)
+		fmt.Printf("id:        %d\n", id)
+		fmt.Printf("time:      %s\n", parsed.GetTime().Format(time.RFC3339Nano))
+		fmt.Printf("node:      %d\n", parsed.Node)
+		fmt.Printf("sequence:  %d\n", parsed.Sequence)
+	}
+	return nil
+}
+
+func migrate(args []string) error {
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+	from := newLayoutFlags(fs, "from-")
+	to := newLayoutFlags(fs, "to-")
+	_ = fs.Parse(args)
+
+	ids, err := readIDs(fs.Args())
+	if err != nil {
+		return err
+	}
+
+	// 重新编码保留原id的node, 不需要真实的node id
+	fromAlgorithm, err := snowflake.New(1, from.options()...)
+	if err != nil {
+		return fmt.Errorf("invalid old layout: %w", err)
+	}
+	toAlgorithm, err := snowflake.New(1, to.options()...)
+	if err != nil {
+		return fmt.Errorf("invalid new layout: %w", err)
+	}
+
+	report := snowflake.Migrate(fromAlgorithm, toAlgorithm, ids)
+	for _, id := range ids {
+		if newId, exists := report.Mapping[id]; exists {
+			fmt.Printf("%d %d\n", id, newId)
+		}
+	}
+
+	if len(report.Failed) == 0 {
+		return nil
+	}
+	for _, id := range ids {
+		if err, exists := report.Failed[id]; exists {
+			fmt.Fprintf(os.Stderr, "failed %d: %v\n", id, err)
+		}
+	}
+	return fmt.Errorf("%d of %d ids cannot be migrated", len(report.Failed), len(ids))
+}
+
+// readIDs parse the ids in args, or one per line on stdin if args is empty.
+func readIDs(args []string) ([]uint64, error) {
+	if len(args) == 0 {
+		scanner := bufio.NewScanner(os.Stdin)
+		for scanner.Scan() {
+			if line := strings.TrimSpace(scanner.Text()); line != "" {
+				args = append(args, line)
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	ids := make([]uint64, 0, len(args))
+	for _, arg := range args {
+		id, err := snowflake.ParseString(arg)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
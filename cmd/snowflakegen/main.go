@@ -0,0 +1,148 @@
+// Command snowflakegen generates strongly-typed snowflake id wrappers whose
+// JSON, text and database/sql marshaling delegates to github.com/hdget/snowflake.
+//
+// Usage:
+//
+//	//go:generate go run github.com/hdget/snowflake/cmd/snowflakegen -type OrderID,UserID
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"go/format"
+	"go/token"
+	"os"
+	"strings"
+	"text/template"
+)
+
+var (
+	typeNames   = flag.String("type", "", "comma-separated list of type names, required")
+	output      = flag.String("output", "", "output file name, default snowflake_ids.go")
+	packageName = flag.String("package", os.Getenv("GOPACKAGE"), "package name, default $GOPACKAGE")
+)
+
+var tmpl = template.Must(template.New("ids").Parse(`// Code generated by snowflakegen. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	"database/sql/driver"
+	"strconv"
+
+	"github.com/hdget/snowflake"
+)
+{{range .Types}}
+// {{.}} is a snowflake id.
+type {{.}} uint64
+
+// Uint64 returns the raw snowflake id.
+func (i {{.}}) Uint64() uint64 {
+	return uint64(i)
+}
+
+// String returns the decimal form of the id.
+func (i {{.}}) String() string {
+	return strconv.FormatUint(uint64(i), 10)
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (i {{.}}) MarshalText() ([]byte, error) {
+	return snowflake.MarshalIDText(uint64(i))
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (i *{{.}}) UnmarshalText(text []byte) error {
+	id, err := snowflake.UnmarshalIDText(text)
+	if err != nil {
+		return err
+	}
+	*i = {{.}}(id)
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler.
+func (i {{.}}) MarshalJSON() ([]byte, error) {
+	return snowflake.MarshalIDJSON(uint64(i))
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (i *{{.}}) UnmarshalJSON(data []byte) error {
+	id, err := snowflake.UnmarshalIDJSON(data)
+	if err != nil {
+		return err
+	}
+	*i = {{.}}(id)
+	return nil
+}
+
+// Value implements driver.Valuer.
+func (i {{.}}) Value() (driver.Value, error) {
+	return snowflake.ValueID(uint64(i))
+}
+
+// Scan implements sql.Scanner.
+func (i *{{.}}) Scan(src any) error {
+	id, err := snowflake.ScanID(src)
+	if err != nil {
+		return err
+	}
+	*i = {{.}}(id)
+	return nil
+}
+{{end}}`))
+
+func main() {
+	flag.Parse()
+
+	if err := run(); err != nil {
+		fmt.Fprintln(os.Stderr, "snowflakegen:", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	if *packageName == "" {
+		return errors.New("package name is required, run by go generate or set -package")
+	}
+
+	var types []string
+	for _, name := range strings.Split(*typeNames, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if !token.IsIdentifier(name) {
+			return fmt.Errorf("invalid type name: %q", name)
+		}
+		types = append(types, name)
+	}
+	if len(types) == 0 {
+		return errors.New("at least one type name is required")
+	}
+
+	var buf bytes.Buffer
+	err := tmpl.Execute(&buf, struct {
+		Package string
+		Types   []string
+	}{
+		Package: *packageName,
+		Types:   types,
+	})
+	if err != nil {
+		return err
+	}
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return err
+	}
+
+	filename := *output
+	if filename == "" {
+		filename = "snowflake_ids.go"
+	}
+	return os.WriteFile(filename, src, 0o644)
+}
@@ -0,0 +1,47 @@
+// Command snowflakeserver runs a standalone snowflake id service, see package server
+// for the endpoints.
+//
+// Usage:
+//
+//	snowflakeserver -addr :8080 -node 3 [-epoch 1288834974657] [-node-bits 3] [-sequence-bits 7]
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+
+	"github.com/hdget/snowflake"
+	"github.com/hdget/snowflake/server"
+)
+
+var (
+	addr         = flag.String("addr", ":8080", "listen address")
+	node         = flag.Uint64("node", 0, "node id, required")
+	epoch        = flag.Int64("epoch", 0, "epoch in unix milliseconds, default the twitter epoch")
+	nodeBits     = flag.Uint("node-bits", 0, "node bits, default 3")
+	sequenceBits = flag.Uint("sequence-bits", 0, "sequence bits, default 7")
+)
+
+func main() {
+	flag.Parse()
+
+	var options []snowflake.Option
+	if *epoch != 0 {
+		options = append(options, snowflake.WithEpochMillis(*epoch))
+	}
+	if *nodeBits != 0 {
+		options = append(options, snowflake.WithNodeBits(uint8(*nodeBits)))
+	}
+	if *sequenceBits != 0 {
+		options = append(options, snowflake.WithSequenceBits(uint8(*sequenceBits)))
+	}
+
+	a, err := snowflake.New(*node, options...)
+	if err != nil {
+		log.Fatalf("snowflakeserver: %v", err)
+	}
+
+	log.Printf("snowflakeserver: listening on %s", *addr)
+	log.Fatal(http.ListenAndServe(*addr, server.NewHandler(a)))
+}
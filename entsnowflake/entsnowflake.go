@@ -0,0 +1,93 @@
+// Package entsnowflake provides the ent schema mixin using snowflake ids as primary keys.
+//
+//	func (User) Mixin() []ent.Mixin {
+//		return []ent.Mixin{entsnowflake.Mixin{Generator: generator}}
+//	}
+package entsnowflake
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"entgo.io/ent"
+	"entgo.io/ent/schema/field"
+	"entgo.io/ent/schema/mixin"
+)
+
+// Generator generates the ids, *snowflake.Algorithm implements it.
+type Generator interface {
+	NextID() (uint64, error)
+}
+
+// Mixin defines the id field and a hook filling it from Generator on creation.
+// The id is stored as int64 by default, or as a decimal string if AsString is set.
+type Mixin struct {
+	mixin.Schema
+	Generator Generator
+	AsString  bool
+}
+
+// Fields of the mixin.
+func (m Mixin) Fields() []ent.Field {
+	if m.AsString {
+		return []ent.Field{field.String("id").NotEmpty().Immutable()}
+	}
+	return []ent.Field{field.Int64("id").Positive().Immutable()}
+}
+
+// Hooks of the mixin.
+func (m Mixin) Hooks() []ent.Hook {
+	return []ent.Hook{Hook(m.Generator)}
+}
+
+// Hook returns an ent hook setting the id of the created entity from gen unless it is set explicitly.
+// It works with both int64 and string id fields.
+func Hook(gen Generator) ent.Hook {
+	return func(next ent.Mutator) ent.Mutator {
+		return ent.MutateFunc(func(ctx context.Context, m ent.Mutation) (ent.Value, error) {
+			if !m.Op().Is(ent.OpCreate) {
+				return next.Mutate(ctx, m)
+			}
+
+			switch mu := m.(type) {
+			case interface {
+				ID() (int64, bool)
+				SetID(int64)
+			}:
+				if _, exists := mu.ID(); !exists {
+					id, err := nextInt64(gen)
+					if err != nil {
+						return nil, err
+					}
+					mu.SetID(id)
+				}
+			case interface {
+				ID() (string, bool)
+				SetID(string)
+			}:
+				if _, exists := mu.ID(); !exists {
+					id, err := gen.NextID()
+					if err != nil {
+						return nil, err
+					}
+					mu.SetID(strconv.FormatUint(id, 10))
+				}
+			default:
+				return nil, fmt.Errorf("entsnowflake: unsupported id type of mutation %T", m)
+			}
+			return next.Mutate(ctx, m)
+		})
+	}
+}
+
+func nextInt64(gen Generator) (int64, error) {
+	id, err := gen.NextID()
+	if err != nil {
+		return 0, err
+	}
+	if int64(id) < 0 {
+		return 0, fmt.Errorf("entsnowflake: snowflake id %d overflows int64", id)
+	}
+	return int64(id), nil
+}
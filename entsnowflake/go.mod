@@ -0,0 +1,5 @@
+module github.com/hdget/snowflake/entsnowflake
+
+go 1.24
+
+require entgo.io/ent v0.14.6
@@ -0,0 +1,157 @@
+// Package prometheus provides a snowflake metrics implementation exporting to Prometheus.
+//
+//	import snowprom "github.com/hdget/snowflake/metrics/prometheus"
+//
+//	metrics := snowprom.New(snowprom.WithConstLabels(map[string]string{"generator": "orders"}))
+//	prometheus.MustRegister(metrics)
+//	generator, err := snowflake.New(1, snowflake.WithMetrics(metrics))
+package prometheus
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const namespace = "snowflake"
+
+// Metrics implements snowflake.Metrics and prometheus.Collector:
+//
+//	snowflake_ids_issued_total              counter
+//	snowflake_sequence_exhausted_total      counter
+//	snowflake_sequence_wait_seconds         histogram
+//	snowflake_clock_backwards_total         counter
+//	snowflake_clock_backwards_drift_seconds histogram
+//	snowflake_clock_backwards_wait_seconds  histogram
+//	snowflake_generation_latency_seconds    histogram
+type Metrics struct {
+	issued         prometheus.Counter
+	exhausted      prometheus.Counter
+	wait           prometheus.Histogram
+	clockBackwards prometheus.Counter
+	drift          prometheus.Histogram
+	backwardsWait  prometheus.Histogram
+	latency        prometheus.Histogram
+}
+
+type config struct {
+	constLabels prometheus.Labels
+	buckets     []float64
+}
+
+type Option func(c *config)
+
+// WithConstLabels set the constant labels of the metrics, e.g. to tell generators apart.
+func WithConstLabels(labels map[string]string) Option {
+	return func(c *config) {
+		c.constLabels = labels
+	}
+}
+
+// WithLatencyBuckets set the buckets of the generation latency histogram in seconds,
+// the default buckets range from 100ns to about 100ms.
+func WithLatencyBuckets(buckets []float64) Option {
+	return func(c *config) {
+		c.buckets = buckets
+	}
+}
+
+// New create the Prometheus metrics, register them with prometheus.MustRegister.
+func New(options ...Option) *Metrics {
+	c := &config{
+		buckets: prometheus.ExponentialBuckets(100e-9, 4, 11),
+	}
+	for _, apply := range options {
+		apply(c)
+	}
+
+	return &Metrics{
+		issued: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace:   namespace,
+			Name:        "ids_issued_total",
+			Help:        "The number of issued snowflake ids.",
+			ConstLabels: c.constLabels,
+		}),
+		exhausted: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace:   namespace,
+			Name:        "sequence_exhausted_total",
+			Help:        "The number of waits for the next millisecond as the sequences were used up.",
+			ConstLabels: c.constLabels,
+		}),
+		wait: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace:   namespace,
+			Name:        "sequence_wait_seconds",
+			Help:        "The time waited for the next millisecond as the sequences were used up.",
+			ConstLabels: c.constLabels,
+			Buckets:     prometheus.ExponentialBuckets(1e-6, 4, 10),
+		}),
+		clockBackwards: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace:   namespace,
+			Name:        "clock_backwards_total",
+			Help:        "The number of times the clock was detected going backwards.",
+			ConstLabels: c.constLabels,
+		}),
+		drift: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace:   namespace,
+			Name:        "clock_backwards_drift_seconds",
+			Help:        "How far the clock went backwards.",
+			ConstLabels: c.constLabels,
+			Buckets:     prometheus.ExponentialBuckets(1e-3, 4, 10),
+		}),
+		backwardsWait: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace:   namespace,
+			Name:        "clock_backwards_wait_seconds",
+			Help:        "The time waited for the clock to catch up after it went backwards.",
+			ConstLabels: c.constLabels,
+			Buckets:     prometheus.ExponentialBuckets(1e-3, 4, 10),
+		}),
+		latency: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace:   namespace,
+			Name:        "generation_latency_seconds",
+			Help:        "The latency of generating one snowflake id.",
+			ConstLabels: c.constLabels,
+			Buckets:     c.buckets,
+		}),
+	}
+}
+
+// Issued implements snowflake.Metrics.
+func (m *Metrics) Issued(latency time.Duration) {
+	m.issued.Inc()
+	m.latency.Observe(latency.Seconds())
+}
+
+// Waited implements snowflake.Metrics.
+func (m *Metrics) Waited(wait time.Duration) {
+	m.exhausted.Inc()
+	m.wait.Observe(wait.Seconds())
+}
+
+// ClockBackwards implements snowflake.Metrics.
+func (m *Metrics) ClockBackwards(drift time.Duration) {
+	m.clockBackwards.Inc()
+	m.drift.Observe(drift.Seconds())
+}
+
+// ClockBackwardsWaited implements snowflake.Metrics.
+func (m *Metrics) ClockBackwardsWaited(wait time.Duration) {
+	m.backwardsWait.Observe(wait.Seconds())
+}
+
+// Describe implements prometheus.Collector.
+func (m *Metrics) Describe(ch chan<- *prometheus.Desc) {
+	for _, c := range m.collectors() {
+		c.Describe(ch)
+	}
+}
+
+// Collect implements prometheus.Collector.
+func (m *Metrics) Collect(ch chan<- prometheus.Metric) {
+	for _, c := range m.collectors() {
+		c.Collect(ch)
+	}
+}
+
+func (m *Metrics) collectors() []prometheus.Collector {
+	return []prometheus.Collector{m.issued, m.exhausted, m.wait, m.clockBackwards, m.drift, m.backwardsWait, m.latency}
+}
@@ -0,0 +1,31 @@
+// Package echomiddleware provides the echo adapter of the request id middleware.
+package echomiddleware
+
+import (
+	"github.com/hdget/snowflake/middleware"
+	"github.com/labstack/echo/v4"
+)
+
+// RequestID returns an echo middleware which generates a request id for each request,
+// sets it to the response header and stores it in the echo context under middleware.ContextKey.
+func RequestID(gen middleware.Generator) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			id, err := gen.NextID()
+			if err != nil {
+				return err
+			}
+
+			c.Set(middleware.ContextKey, id)
+			c.Response().Header().Set(middleware.HeaderName, middleware.Format(id))
+			c.SetRequest(c.Request().WithContext(middleware.NewContext(c.Request().Context(), id)))
+			return next(c)
+		}
+	}
+}
+
+// Get returns the request id stored in the echo context.
+func Get(c echo.Context) (uint64, bool) {
+	id, ok := c.Get(middleware.ContextKey).(uint64)
+	return id, ok
+}
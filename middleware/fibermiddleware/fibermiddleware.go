@@ -0,0 +1,28 @@
+// Package fibermiddleware provides the fiber adapter of the request id middleware.
+package fibermiddleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/hdget/snowflake/middleware"
+)
+
+// RequestID returns a fiber middleware which generates a request id for each request,
+// sets it to the response header and stores it in the fiber locals under middleware.ContextKey.
+func RequestID(gen middleware.Generator) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id, err := gen.NextID()
+		if err != nil {
+			return err
+		}
+
+		c.Locals(middleware.ContextKey, id)
+		c.Set(middleware.HeaderName, middleware.Format(id))
+		return c.Next()
+	}
+}
+
+// Get returns the request id stored in the fiber locals.
+func Get(c *fiber.Ctx) (uint64, bool) {
+	id, ok := c.Locals(middleware.ContextKey).(uint64)
+	return id, ok
+}
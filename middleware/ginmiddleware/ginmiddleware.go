@@ -0,0 +1,36 @@
+// Package ginmiddleware provides the gin adapter of the request id middleware.
+package ginmiddleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hdget/snowflake/middleware"
+)
+
+// RequestID returns a gin middleware which generates a request id for each request,
+// sets it to the response header and stores it in the gin context under middleware.ContextKey.
+func RequestID(gen middleware.Generator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := gen.NextID()
+		if err != nil {
+			c.AbortWithStatus(http.StatusInternalServerError)
+			return
+		}
+
+		c.Set(middleware.ContextKey, id)
+		c.Header(middleware.HeaderName, middleware.Format(id))
+		c.Request = c.Request.WithContext(middleware.NewContext(c.Request.Context(), id))
+		c.Next()
+	}
+}
+
+// Get returns the request id stored in the gin context.
+func Get(c *gin.Context) (uint64, bool) {
+	v, exists := c.Get(middleware.ContextKey)
+	if !exists {
+		return 0, false
+	}
+	id, ok := v.(uint64)
+	return id, ok
+}
@@ -0,0 +1,58 @@
+// Package middleware provides request id middlewares backed by a snowflake generator.
+// The net/http middleware lives in this package, the framework adapters live in
+// the ginmiddleware, echomiddleware and fibermiddleware subpackages.
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+)
+
+// HeaderName is the response header carrying the request id.
+const HeaderName = "X-Request-ID"
+
+// ContextKey is the key the framework adapters store the request id under
+// in the framework's context, e.g. gin.Context.Get(ContextKey).
+const ContextKey = "snowflake.requestId"
+
+// Generator generates the request ids, *snowflake.Algorithm implements it.
+type Generator interface {
+	NextID() (uint64, error)
+}
+
+type contextKey struct{}
+
+// RequestID returns a net/http middleware which generates a request id for each request,
+// sets it to the response header and stores it in the request context.
+// The request fails with 500 if the id cannot be generated.
+func RequestID(gen Generator) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id, err := gen.NextID()
+			if err != nil {
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				return
+			}
+
+			w.Header().Set(HeaderName, Format(id))
+			next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), id)))
+		})
+	}
+}
+
+// NewContext returns a copy of ctx carrying the request id.
+func NewContext(ctx context.Context, id uint64) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the request id stored in ctx.
+func FromContext(ctx context.Context) (uint64, bool) {
+	id, ok := ctx.Value(contextKey{}).(uint64)
+	return id, ok
+}
+
+// Format returns the header form of the request id.
+func Format(id uint64) string {
+	return strconv.FormatUint(id, 10)
+}
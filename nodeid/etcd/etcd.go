@@ -0,0 +1,183 @@
+// Package etcd provides a snowflake node id provider acquiring a unique node id via an etcd lease.
+//
+//	provider := etcd.New(client)
+//	defer provider.Close()
+//	generator, err := snowflake.New(0, snowflake.WithNodeIDProvider(provider))
+package etcd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+const (
+	defaultPrefix  = "/snowflake/nodes/"
+	defaultTTL     = 10 * time.Second
+	defaultTimeout = 5 * time.Second
+)
+
+// Provider acquires a node id by creating the key <prefix><node id> attached to a lease,
+// the lease is kept alive by a heartbeat until Close.
+type Provider struct {
+	client  *clientv3.Client
+	prefix  string
+	ttl     time.Duration
+	timeout time.Duration
+
+	mu      sync.Mutex
+	leaseId clientv3.LeaseID
+	nodeId  uint64
+	cancel  context.CancelFunc
+	lost    chan struct{}
+}
+
+type Option func(p *Provider)
+
+// WithPrefix set the key prefix of the node ids, default is /snowflake/nodes/.
+func WithPrefix(prefix string) Option {
+	return func(p *Provider) {
+		p.prefix = prefix
+	}
+}
+
+// WithTTL set the lease ttl, the node id is released if the heartbeat stops for ttl, default is 10s.
+func WithTTL(ttl time.Duration) Option {
+	return func(p *Provider) {
+		p.ttl = ttl
+	}
+}
+
+// WithTimeout set the timeout of acquiring and releasing the node id, default is 5s.
+func WithTimeout(timeout time.Duration) Option {
+	return func(p *Provider) {
+		p.timeout = timeout
+	}
+}
+
+// New create an etcd node id provider.
+func New(client *clientv3.Client, options ...Option) *Provider {
+	p := &Provider{
+		client:  client,
+		prefix:  defaultPrefix,
+		ttl:     defaultTTL,
+		timeout: defaultTimeout,
+		lost:    make(chan struct{}),
+	}
+	for _, apply := range options {
+		apply(p)
+	}
+	return p
+}
+
+// NodeID acquire the first free node id in [1, maxNode] and start the heartbeat.
+func (p *Provider) NodeID(maxNode uint32) (uint64, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.cancel != nil {
+		return p.nodeId, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+	defer cancel()
+
+	ttl := int64(p.ttl / time.Second)
+	if ttl < 1 {
+		ttl = 1
+	}
+	lease, err := p.client.Grant(ctx, ttl)
+	if err != nil {
+		return 0, fmt.Errorf("grant etcd lease: %w", err)
+	}
+
+	holder := holderName()
+	for id := uint64(1); id <= uint64(maxNode); id++ {
+		key := p.prefix + strconv.FormatUint(id, 10)
+		resp, err := p.client.Txn(ctx).
+			If(clientv3.Compare(clientv3.CreateRevision(key), "=", 0)).
+			Then(clientv3.OpPut(key, holder, clientv3.WithLease(lease.ID))).
+			Commit()
+		if err != nil {
+			_, _ = p.client.Revoke(context.Background(), lease.ID)
+			return 0, fmt.Errorf("claim etcd node id: %w", err)
+		}
+		if !resp.Succeeded {
+			continue
+		}
+
+		// 上一次的lease丢失时lost已关闭
+		select {
+		case <-p.lost:
+			p.lost = make(chan struct{})
+		default:
+		}
+		if err = p.keepAlive(lease.ID); err != nil {
+			_, _ = p.client.Revoke(context.Background(), lease.ID)
+			return 0, err
+		}
+		p.leaseId = lease.ID
+		p.nodeId = id
+		return id, nil
+	}
+
+	_, _ = p.client.Revoke(context.Background(), lease.ID)
+	return 0, errors.New("no free node id in etcd")
+}
+
+// Lost returns a channel which is closed when the lease is lost, e.g. the heartbeat failed
+// for longer than the ttl. The application should stop issuing ids from then on.
+func (p *Provider) Lost() <-chan struct{} {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.lost
+}
+
+// Close stop the heartbeat and release the node id.
+func (p *Provider) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.cancel == nil {
+		return nil
+	}
+	p.cancel()
+	p.cancel = nil
+
+	ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+	defer cancel()
+	_, err := p.client.Revoke(ctx, p.leaseId)
+	return err
+}
+
+func (p *Provider) keepAlive(leaseId clientv3.LeaseID) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	ch, err := p.client.KeepAlive(ctx, leaseId)
+	if err != nil {
+		cancel()
+		return fmt.Errorf("keep etcd lease alive: %w", err)
+	}
+	p.cancel = cancel
+	lost := p.lost
+
+	go func() {
+		for range ch {
+		}
+		// 主动Close时不算丢失
+		if ctx.Err() == nil {
+			close(lost)
+		}
+	}()
+	return nil
+}
+
+func holderName() string {
+	hostname, _ := os.Hostname()
+	return fmt.Sprintf("%s/%d", hostname, os.Getpid())
+}
@@ -0,0 +1,102 @@
+// Package filelock provides a snowflake node id provider locking a file per node id in a local
+// directory, giving collision-free node ids to the processes on one machine without any
+// external service.
+//
+//	provider := filelock.New("/var/run/snowflake")
+//	defer provider.Close()
+//	generator, err := snowflake.New(0, snowflake.WithNodeIDProvider(provider))
+package filelock
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+)
+
+// filePrefix is the name prefix of the lock files
+const filePrefix = "node-"
+
+// errLocked is returned by tryLock when the file is locked by another process.
+var errLocked = errors.New("the file is locked")
+
+// Provider scans the lock files <dir>/node-1 … <dir>/node-<maxNode> and flocks the first free
+// one, the node id is the number of the file. The lock is held until Close or the process
+// exits, so a crashed process never keeps its node id.
+type Provider struct {
+	dir string
+
+	mu     sync.Mutex
+	file   *os.File
+	nodeId uint64
+}
+
+// New create a file lock node id provider using the lock files in dir, it is created if needed.
+func New(dir string) *Provider {
+	return &Provider{dir: dir}
+}
+
+// NodeID lock the first free lock file and return its node id in [1, maxNode].
+func (p *Provider) NodeID(maxNode uint32) (uint64, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.file != nil {
+		return p.nodeId, nil
+	}
+
+	if err := os.MkdirAll(p.dir, 0o755); err != nil {
+		return 0, fmt.Errorf("create lock directory: %w", err)
+	}
+
+	for id := uint64(1); id <= uint64(maxNode); id++ {
+		f, err := os.OpenFile(filepath.Join(p.dir, filePrefix+strconv.FormatUint(id, 10)), os.O_RDWR|os.O_CREATE, 0o644)
+		if err != nil {
+			return 0, fmt.Errorf("open lock file: %w", err)
+		}
+
+		if err = tryLock(f); err != nil {
+			_ = f.Close()
+			if errors.Is(err, errLocked) {
+				continue
+			}
+			return 0, fmt.Errorf("lock file %s: %w", f.Name(), err)
+		}
+
+		// 记录持有者, 仅用于排查
+		if err = f.Truncate(0); err == nil {
+			_, err = f.WriteAt([]byte(holderName()+"\n"), 0)
+		}
+		if err != nil {
+			_ = f.Close()
+			return 0, fmt.Errorf("write lock file %s: %w", f.Name(), err)
+		}
+
+		p.file = f
+		p.nodeId = id
+		return id, nil
+	}
+	return 0, errors.New("no free node id in the lock directory")
+}
+
+// Close release the lock and the node id, the lock file is kept for the next process.
+func (p *Provider) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.file == nil {
+		return nil
+	}
+
+	// 关闭文件即释放锁
+	err := p.file.Close()
+	p.file = nil
+	return err
+}
+
+func holderName() string {
+	hostname, _ := os.Hostname()
+	return fmt.Sprintf("%s/%d", hostname, os.Getpid())
+}
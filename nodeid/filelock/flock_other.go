@@ -0,0 +1,13 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly)
+
+package filelock
+
+import (
+	"errors"
+	"os"
+)
+
+// tryLock is not supported on this platform.
+func tryLock(f *os.File) error {
+	return errors.New("file locks are not supported on this platform")
+}
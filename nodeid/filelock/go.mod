@@ -0,0 +1,3 @@
+module github.com/hdget/snowflake/nodeid/filelock
+
+go 1.23
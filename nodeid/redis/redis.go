@@ -0,0 +1,194 @@
+// Package redis provides a snowflake node id provider claiming a unique node id in redis.
+//
+//	provider := redis.New(client)
+//	defer provider.Close()
+//	generator, err := snowflake.New(0, snowflake.WithNodeIDProvider(provider))
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	defaultPrefix  = "snowflake:nodes:"
+	defaultTTL     = 10 * time.Second
+	defaultTimeout = 5 * time.Second
+	// ttl的下限, 每三分之一ttl续期一次
+	minTTL = time.Second
+)
+
+var (
+	// 只有仍持有node id时才续期/释放
+	refreshScript = redis.NewScript(`if redis.call("GET", KEYS[1]) == ARGV[1] then return redis.call("PEXPIRE", KEYS[1], ARGV[2]) end return 0`)
+	releaseScript = redis.NewScript(`if redis.call("GET", KEYS[1]) == ARGV[1] then return redis.call("DEL", KEYS[1]) end return 0`)
+)
+
+// Provider claims a node id by SET NX of the key <prefix><node id> with a ttl,
+// the ttl is refreshed in the background until Close.
+type Provider struct {
+	client  redis.UniversalClient
+	prefix  string
+	ttl     time.Duration
+	timeout time.Duration
+	holder  string
+
+	mu     sync.Mutex
+	key    string
+	nodeId uint64
+	cancel context.CancelFunc
+	done   chan struct{}
+	lost   chan struct{}
+}
+
+type Option func(p *Provider)
+
+// WithPrefix set the key prefix of the node ids, default is snowflake:nodes:.
+func WithPrefix(prefix string) Option {
+	return func(p *Provider) {
+		p.prefix = prefix
+	}
+}
+
+// WithTTL set the ttl of the claim, the node id is released if it is not refreshed for ttl,
+// default is 10s, and it must be at least 1s. The claim is refreshed every third of the ttl.
+func WithTTL(ttl time.Duration) Option {
+	return func(p *Provider) {
+		p.ttl = ttl
+	}
+}
+
+// WithTimeout set the timeout of each redis call, default is 5s.
+func WithTimeout(timeout time.Duration) Option {
+	return func(p *Provider) {
+		p.timeout = timeout
+	}
+}
+
+// New create a redis node id provider.
+func New(client redis.UniversalClient, options ...Option) *Provider {
+	hostname, _ := os.Hostname()
+	p := &Provider{
+		client:  client,
+		prefix:  defaultPrefix,
+		ttl:     defaultTTL,
+		timeout: defaultTimeout,
+		holder:  fmt.Sprintf("%s/%d/%d", hostname, os.Getpid(), time.Now().UnixNano()),
+		lost:    make(chan struct{}),
+	}
+	for _, apply := range options {
+		apply(p)
+	}
+	return p
+}
+
+// NodeID claim the first free node id in [1, maxNode] and start refreshing the claim.
+func (p *Provider) NodeID(maxNode uint32) (uint64, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.cancel != nil {
+		return p.nodeId, nil
+	}
+
+	// 0会创建永不过期的key, 过小的ttl来不及续期
+	if p.ttl < minTTL {
+		return 0, fmt.Errorf("the redis node id ttl must be at least %v", minTTL)
+	}
+
+	for id := uint64(1); id <= uint64(maxNode); id++ {
+		key := p.prefix + strconv.FormatUint(id, 10)
+
+		ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+		ok, err := p.client.SetNX(ctx, key, p.holder, p.ttl).Result()
+		cancel()
+		if err != nil {
+			return 0, fmt.Errorf("claim redis node id: %w", err)
+		}
+		if !ok {
+			continue
+		}
+
+		p.key = key
+		p.nodeId = id
+		// 上一次的claim丢失时lost已关闭
+		select {
+		case <-p.lost:
+			p.lost = make(chan struct{})
+		default:
+		}
+		p.startRefresh()
+		return id, nil
+	}
+	return 0, errors.New("no free node id in redis")
+}
+
+// Lost returns a channel which is closed when the claim is lost, e.g. the key expired or
+// was taken by another holder. The application should stop issuing ids from then on.
+func (p *Provider) Lost() <-chan struct{} {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.lost
+}
+
+// Close stop refreshing and release the node id.
+func (p *Provider) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.cancel == nil {
+		return nil
+	}
+	p.cancel()
+	<-p.done
+	p.cancel = nil
+
+	ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+	defer cancel()
+	return releaseScript.Run(ctx, p.client, []string{p.key}, p.holder).Err()
+}
+
+func (p *Provider) startRefresh() {
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cancel = cancel
+	p.done = make(chan struct{})
+	done, lost := p.done, p.lost
+
+	go func() {
+		defer close(done)
+
+		ticker := time.NewTicker(p.ttl / 3)
+		defer ticker.Stop()
+
+		lastRefresh := time.Now()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			callCtx, callCancel := context.WithTimeout(ctx, p.timeout)
+			n, err := refreshScript.Run(callCtx, p.client, []string{p.key}, p.holder, p.ttl.Milliseconds()).Int()
+			callCancel()
+
+			switch {
+			case ctx.Err() != nil:
+				return
+			case err == nil && n == 1:
+				lastRefresh = time.Now()
+			case err == nil || time.Since(lastRefresh) >= p.ttl:
+				// key已不属于自己, 或者在ttl内一直没能续期
+				close(lost)
+				return
+			}
+		}
+	}()
+}
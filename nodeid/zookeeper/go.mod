@@ -0,0 +1,5 @@
+module github.com/hdget/snowflake/nodeid/zookeeper
+
+go 1.23
+
+require github.com/go-zookeeper/zk v1.0.4
@@ -0,0 +1,243 @@
+// Package zookeeper provides a snowflake node id provider allocating the node id from an
+// ephemeral sequential znode, as in the original twitter stack.
+//
+//	provider := zookeeper.New(conn)
+//	defer provider.Close()
+//	generator, err := snowflake.New(0, snowflake.WithNodeIDProvider(provider))
+package zookeeper
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/go-zookeeper/zk"
+)
+
+const (
+	defaultPath = "/snowflake/nodes"
+	// nodePrefix is the name prefix of the sequential znodes
+	nodePrefix = "node-"
+)
+
+// Provider creates the ephemeral sequential znode <path>/node-<sequence> and uses its
+// sequence modulo maxNode as the node id. The znode lives as long as the zookeeper session,
+// a znode whose node id collides with a live one is replaced by a new one.
+type Provider struct {
+	conn *zk.Conn
+	path string
+	acl  []zk.ACL
+
+	mu     sync.Mutex
+	znode  string
+	nodeId uint64
+	closed chan struct{}
+	lost   chan struct{}
+}
+
+type Option func(p *Provider)
+
+// WithPath set the parent path of the znodes, default is /snowflake/nodes.
+func WithPath(path string) Option {
+	return func(p *Provider) {
+		p.path = strings.TrimRight(path, "/")
+	}
+}
+
+// WithACL set the acl of the created znodes, default is world:anyone with all permissions.
+func WithACL(acl []zk.ACL) Option {
+	return func(p *Provider) {
+		p.acl = acl
+	}
+}
+
+// New create a zookeeper node id provider.
+func New(conn *zk.Conn, options ...Option) *Provider {
+	p := &Provider{
+		conn: conn,
+		path: defaultPath,
+		acl:  zk.WorldACL(zk.PermAll),
+		lost: make(chan struct{}),
+	}
+	for _, apply := range options {
+		apply(p)
+	}
+	return p
+}
+
+// NodeID create the ephemeral sequential znode and return the node id in [1, maxNode] derived
+// from its sequence, then watch the znode for the session loss.
+func (p *Provider) NodeID(maxNode uint32) (uint64, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.znode != "" {
+		return p.nodeId, nil
+	}
+
+	if err := p.ensurePath(); err != nil {
+		return 0, err
+	}
+
+	// 冲突时删除重建, 每次重建得到新的sequence
+	for attempt := uint32(0); attempt < maxNode; attempt++ {
+		znode, err := p.conn.Create(p.path+"/"+nodePrefix, []byte(holderName()), zk.FlagEphemeral|zk.FlagSequence, p.acl)
+		if err != nil {
+			return 0, fmt.Errorf("create zookeeper znode: %w", err)
+		}
+
+		seq, err := parseSequence(znode[strings.LastIndex(znode, "/")+1:])
+		if err != nil {
+			_ = p.conn.Delete(znode, -1)
+			return 0, err
+		}
+		nodeId := seq%uint64(maxNode) + 1
+
+		collided, err := p.collides(znode, nodeId, maxNode)
+		if err != nil {
+			_ = p.conn.Delete(znode, -1)
+			return 0, err
+		}
+		if collided {
+			_ = p.conn.Delete(znode, -1)
+			continue
+		}
+
+		// 每次获取node id使用新的channel, 上一次Close已关闭closed, 上一次丢失已关闭lost
+		p.closed = make(chan struct{})
+		select {
+		case <-p.lost:
+			p.lost = make(chan struct{})
+		default:
+		}
+		if err = p.watch(znode); err != nil {
+			_ = p.conn.Delete(znode, -1)
+			return 0, err
+		}
+		p.znode = znode
+		p.nodeId = nodeId
+		return nodeId, nil
+	}
+	return 0, errors.New("no free node id in zookeeper")
+}
+
+// Lost returns a channel which is closed when the znode is lost, e.g. the zookeeper session
+// expired. The application should stop issuing ids from then on.
+func (p *Provider) Lost() <-chan struct{} {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.lost
+}
+
+// Close delete the znode and release the node id.
+func (p *Provider) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.znode == "" {
+		return nil
+	}
+	close(p.closed)
+
+	err := p.conn.Delete(p.znode, -1)
+	p.znode = ""
+	if errors.Is(err, zk.ErrNoNode) {
+		return nil
+	}
+	return err
+}
+
+// ensurePath create the parent path of the znodes if it does not exist.
+func (p *Provider) ensurePath() error {
+	path := ""
+	for _, part := range strings.Split(strings.Trim(p.path, "/"), "/") {
+		path += "/" + part
+		_, err := p.conn.Create(path, nil, 0, p.acl)
+		if err != nil && !errors.Is(err, zk.ErrNodeExists) {
+			return fmt.Errorf("create zookeeper path %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// collides check whether another live znode has the same node id.
+func (p *Provider) collides(znode string, nodeId uint64, maxNode uint32) (bool, error) {
+	children, _, err := p.conn.Children(p.path)
+	if err != nil {
+		return false, fmt.Errorf("list zookeeper znodes: %w", err)
+	}
+
+	for _, child := range children {
+		if p.path+"/"+child == znode {
+			continue
+		}
+		seq, err := parseSequence(child)
+		if err != nil {
+			continue
+		}
+		if seq%uint64(maxNode)+1 == nodeId {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// watch close the lost channel once the znode is deleted or the session expired.
+func (p *Provider) watch(znode string) error {
+	exists, _, ch, err := p.conn.ExistsW(znode)
+	if err != nil {
+		return fmt.Errorf("watch zookeeper znode: %w", err)
+	}
+	if !exists {
+		return errors.New("the zookeeper znode disappeared")
+	}
+
+	go p.watchLoop(znode, ch, p.closed, p.lost)
+	return nil
+}
+
+func (p *Provider) watchLoop(znode string, ch <-chan zk.Event, closed, lost chan struct{}) {
+	for {
+		select {
+		case <-closed:
+			return
+		case ev := <-ch:
+			// 数据变化时继续监听, 删除或会话过期时视为丢失
+			if ev.Type == zk.EventNodeDataChanged {
+				exists, _, next, err := p.conn.ExistsW(znode)
+				if err == nil && exists {
+					ch = next
+					continue
+				}
+			}
+
+			select {
+			case <-closed:
+			default:
+				close(lost)
+			}
+			return
+		}
+	}
+}
+
+// parseSequence parse the sequence of the znode name node-<sequence>.
+func parseSequence(name string) (uint64, error) {
+	if !strings.HasPrefix(name, nodePrefix) {
+		return 0, fmt.Errorf("invalid zookeeper znode %s", name)
+	}
+
+	seq, err := strconv.ParseUint(name[len(nodePrefix):], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid zookeeper znode %s: %w", name, err)
+	}
+	return seq, nil
+}
+
+func holderName() string {
+	hostname, _ := os.Hostname()
+	return fmt.Sprintf("%s/%d", hostname, os.Getpid())
+}
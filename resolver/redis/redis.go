@@ -0,0 +1,125 @@
+// Package redis provides a snowflake sequence resolver coordinated by redis, for the deployments
+// sharing one node id across several processes, e.g. blue/green on the same host.
+//
+//	resolver := redis.New(client, nodeId)
+//	generator, err := snowflake.New(nodeId, snowflake.WithSequenceResolver(resolver))
+package redis
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	defaultPrefix  = "snowflake:"
+	defaultTTL     = 10 * time.Second
+	defaultTimeout = time.Second
+)
+
+// 每个tick的第一次INCR设置过期时间
+var incrScript = redis.NewScript(`local n = redis.call("INCR", KEYS[1]) if n == 1 then redis.call("PEXPIRE", KEYS[1], ARGV[1]) end return n`)
+
+// Resolver resolves the sequences by atomically incrementing the key <prefix>node:<id>:<millis>,
+// so the processes sharing the node id never issue the same sequence in a tick, at the cost of
+// a redis round trip per id. The keys expire after the ttl, the clocks of the processes must
+// not differ by more than it.
+type Resolver struct {
+	client  redis.UniversalClient
+	prefix  string
+	ttl     time.Duration
+	timeout time.Duration
+
+	mu       sync.Mutex
+	lastTime int64
+	lastSeq  uint32
+}
+
+type Option func(r *Resolver)
+
+// WithPrefix set the key prefix, default is snowflake:.
+func WithPrefix(prefix string) Option {
+	return func(r *Resolver) {
+		r.prefix = prefix
+	}
+}
+
+// WithTTL set how long the key of a tick is kept, default is 10s.
+func WithTTL(ttl time.Duration) Option {
+	return func(r *Resolver) {
+		r.ttl = ttl
+	}
+}
+
+// WithTimeout set the timeout of each redis call, default is 1s.
+func WithTimeout(timeout time.Duration) Option {
+	return func(r *Resolver) {
+		r.timeout = timeout
+	}
+}
+
+// New create a redis sequence resolver for the node id, all the processes sharing the node id
+// must use the same prefix.
+func New(client redis.UniversalClient, nodeId uint64, options ...Option) *Resolver {
+	r := &Resolver{
+		client:  client,
+		prefix:  defaultPrefix,
+		ttl:     defaultTTL,
+		timeout: defaultTimeout,
+	}
+	for _, apply := range options {
+		apply(r)
+	}
+	r.prefix += "node:" + strconv.FormatUint(nodeId, 10) + ":"
+	return r
+}
+
+// Resolve increment the counter of the tick ms in redis and return the sequence it maps to.
+func (r *Resolver) Resolve(ms int64, start, limit uint32) (uint32, error) {
+	r.mu.Lock()
+	last := r.lastTime
+	r.mu.Unlock()
+	if ms < last {
+		return limit, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.timeout)
+	defer cancel()
+
+	n, err := incrScript.Run(ctx, r.client, []string{r.prefix + strconv.FormatInt(ms, 10)}, r.ttl.Milliseconds()).Int64()
+	if err != nil {
+		return 0, fmt.Errorf("resolve redis sequence: %w", err)
+	}
+	if n < 1 || n > int64(limit)-int64(start) {
+		return limit, nil
+	}
+	seq := start + uint32(n-1)
+
+	r.mu.Lock()
+	if ms > r.lastTime || (ms == r.lastTime && seq > r.lastSeq) {
+		r.lastTime, r.lastSeq = ms, seq
+	}
+	r.mu.Unlock()
+	return seq, nil
+}
+
+// Last returns the last tick and sequence resolved by this process.
+func (r *Resolver) Last() (int64, uint32) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.lastTime, r.lastSeq
+}
+
+// Restore mark the sequences of the tick ms up to seq as used for this process.
+func (r *Resolver) Restore(ms int64, seq uint32) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.lastTime < ms {
+		r.lastTime, r.lastSeq = ms, seq
+	}
+}
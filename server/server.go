@@ -0,0 +1,105 @@
+// Package server provides an embeddable http.Handler serving snowflake ids as JSON,
+// cmd/snowflakeserver runs it as a standalone id service.
+//
+//	GET /id             {"id":"1234"}
+//	GET /ids?count=N    {"ids":["1234","1235"]}
+//	GET /parse/{id}     {"id":"1234","time":"...","node":1,"sequence":0,...}
+//
+// The ids are serialized as strings, so JavaScript clients do not round them.
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/hdget/snowflake"
+)
+
+// MaxCount is the max number of ids returned by one GET /ids request.
+const MaxCount = 1000
+
+type idResponse struct {
+	Id string `json:"id"`
+}
+
+type idsResponse struct {
+	Ids []string `json:"ids"`
+}
+
+type parseResponse struct {
+	Id          string    `json:"id"`
+	Time        time.Time `json:"time"`
+	Node        uint64    `json:"node"`
+	Datacenter  uint64    `json:"datacenter"`
+	Worker      uint64    `json:"worker"`
+	Sequence    uint64    `json:"sequence"`
+	Environment uint64    `json:"environment"`
+	Era         uint64    `json:"era"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// NewHandler returns a http.Handler serving the ids generated by a.
+func NewHandler(a *snowflake.Algorithm) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /id", func(w http.ResponseWriter, r *http.Request) {
+		id, err := a.NextID()
+		if err != nil {
+			writeJSON(w, http.StatusServiceUnavailable, errorResponse{Error: err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, idResponse{Id: strconv.FormatUint(id, 10)})
+	})
+
+	mux.HandleFunc("GET /ids", func(w http.ResponseWriter, r *http.Request) {
+		count, err := strconv.Atoi(r.URL.Query().Get("count"))
+		if err != nil || count < 1 || count > MaxCount {
+			writeJSON(w, http.StatusBadRequest, errorResponse{Error: "count must be between 1 and " + strconv.Itoa(MaxCount)})
+			return
+		}
+
+		ids := make([]string, count)
+		for i := range ids {
+			id, err := a.NextID()
+			if err != nil {
+				writeJSON(w, http.StatusServiceUnavailable, errorResponse{Error: err.Error()})
+				return
+			}
+			ids[i] = strconv.FormatUint(id, 10)
+		}
+		writeJSON(w, http.StatusOK, idsResponse{Ids: ids})
+	})
+
+	mux.HandleFunc("GET /parse/{id}", func(w http.ResponseWriter, r *http.Request) {
+		id, err := snowflake.ParseString(r.PathValue("id"))
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+			return
+		}
+
+		parsed := a.Parse(id)
+		writeJSON(w, http.StatusOK, parseResponse{
+			Id:          strconv.FormatUint(id, 10),
+			Time:        parsed.GetTime(),
+			Node:        parsed.Node,
+			Datacenter:  parsed.Datacenter,
+			Worker:      parsed.Worker,
+			Sequence:    parsed.Sequence,
+			Environment: parsed.Environment,
+			Era:         parsed.Era,
+		})
+	})
+
+	return mux
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
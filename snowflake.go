@@ -3,6 +3,9 @@ package snowflake
 import (
 	"errors"
 	"fmt"
+	"math/big"
+	"strconv"
+	"sync"
 	"sync/atomic"
 	"time"
 )
@@ -10,41 +13,126 @@ import (
 type Algorithm struct {
 	nodeId    uint64
 	startTime time.Time
+	// 时钟tick的精度, 毫秒或微秒, 以下的时间均以tick计
+	precision time.Duration
+	// 时间戳的单位(tick)
+	unit int64
+	// 时间戳取整的粒度(tick)
+	granularity int64
 	// bits
-	nodeBits     uint8
-	sequenceBits uint8 // sequence最多
+	timestampBits uint8
+	nodeBits      uint8
+	sequenceBits  uint8 // sequence最多
+	// 宽id最多63位, 超过JavaScript能安全表示的范围
+	wide bool
+	// 兼容其他实现, 允许node id为0
+	zeroNode bool
+	// 从外部获取node id, 例如网卡或etcd
+	nodeIdProvider NodeIDProvider
+	// era位于timestamp之上, timestamp用尽后进入下一个era
+	eraBits      uint8
+	maxTimestamp uint64
+	maxElapsed   uint64
+	// 环境标识, 位于timestamp和node之间
+	envBits uint8
+	envCode uint64
+	// node的高位为datacenter, 低位为worker
+	datacenterBits uint8
+	datacenterId   uint64
+	workerBits     uint8
+	// sequence位于node之上, 例如sonyflake
+	sequenceHigh bool
 	// 位移长度
+	sequenceMoveLength  uint8
 	nodeMoveLength      uint8
+	envMoveLength       uint8
 	timestampMoveLength uint8
 	// 最大值
 	maxNode     uint32 // node最多10bit
 	maxSequence uint32 // sequence最多12bit
+	// 严格单调模式下记录已发放的最大id
+	strictMonotonic bool
+	highWater       atomic.Uint64
+	// 每个key最后发放的id
+	keys sync.Map
+	// 每毫秒为高优先级调用保留的sequence数量
+	priorityReserve uint32
+	// 每毫秒第一个sequence
+	sequenceStart uint32
+	// 十进制形式命中过滤器的id会被跳过
+	decimalFilters []func(string) bool
+	// 记录/回放(毫秒, sequence)决策
+	recorder *Timeline
+	replay   *Timeline
+	// 事件订阅
+	events broker
+	// 生成指标和统计
+	metrics Metrics
+	stats   stats
+	// 迁移期间兼容的旧layout
+	legacy []*Algorithm
+	// 映射到文件的最后发放id的毫秒
+	mmapState *int64
+	// 定期持久化的发放id的毫秒上限
+	stateFile *stateFile
+	// sequence用完时的处理策略和等待下一毫秒的方式
+	overflowPolicy OverflowPolicy
+	waitStrategy   WaitStrategy
+	// 时钟回拨的处理策略和容忍的最大回拨
+	clockPolicy    ClockBackwardsPolicy
+	clockTolerance time.Duration
+	// 时钟
+	clock Clock
+	// Validate接受的node id, 为nil时接受任何合法的node id
+	knownNodes map[uint64]struct{}
+	// 校验node id仍由本实例唯一持有
+	machineChecker *machineIDChecker
+	// timestamp即将用尽时的告警
+	lifetimeWarning *lifetimeWarning
+	// 对外的id经过keyed permutation混淆
+	obfuscationKey []byte
+	obfuscator     *obfuscator
+	// sequence状态, 每个实例独立
+	sequence SequenceResolver
+	// 多进程共享的映射到文件的sequence状态
+	sharedState *uint64
 }
 
 const (
 	// 1 bit reserved | 41 bit timestamp | 10 bit node | 12 bit sequence
-	timestampBits uint8  = 41
-	maxTimestamp  uint64 = 1<<timestampBits - 1
+	defaultTimestampBits uint8  = 41
+	maxTimestamp         uint64 = 1<<defaultTimestampBits - 1
 	// 缺省的node bits和sequence bits
 	defaultNodeBits     uint8 = 3 // node bits支持2^3=8个节点
 	defaultSequenceBits uint8 = 7 // sequence bits同时一个node同一时间最多生成128个sequence
 	// 缺省的twitter算法的epoch
 	defaultEpoc = int64(1288834974657)
+	// 判断id是否合理时允许的时钟偏差
+	plausibleClockSkew = time.Minute
+	// 十进制过滤器连续拒绝的id上限, 超过后放弃生成
+	maxSkippedIDs = 4096
 )
 
 var (
+	// ErrLifetimeExceeded is returned when the timestamp is before the epoch or beyond the
+	// timestamp bits of the layout.
+	ErrLifetimeExceeded = errors.New("the maximum life cycle of the snowflake algorithm is exceeded, please check starttime")
+	// ErrSkipExhausted is returned when the decimal filters reject 4096 consecutive ids.
+	ErrSkipExhausted = errors.New("too many consecutive ids are rejected by the decimal filters")
 	// 转换成time.Time,对应于2010年11月4日 01:42:54.657 UTC
 	defaultStartTime = time.Unix(defaultEpoc/1000, (defaultEpoc%1000)*1e6)
-	lastTime         int64
-	lastSeq          uint32
 )
 
 func New(nodeId uint64, options ...Option) (*Algorithm, error) {
 	a := &Algorithm{
-		startTime:    defaultStartTime,
-		nodeBits:     defaultNodeBits,
-		sequenceBits: defaultSequenceBits,
+		clock:         systemClock{},
+		precision:     time.Millisecond,
+		startTime:     defaultStartTime,
+		timestampBits: defaultTimestampBits,
+		nodeBits:      defaultNodeBits,
+		sequenceBits:  defaultSequenceBits,
 	}
+	a.stats.created = time.Now()
 
 	for _, apply := range options {
 		err := apply(a)
@@ -54,121 +142,718 @@ func New(nodeId uint64, options ...Option) (*Algorithm, error) {
 	}
 
 	// 在 JavaScript 中，这是能够被安全且准确表示的最大整数为2<<53-1
-	// 这里强制检查node bits + sequence bits不超过63-41=12
-	if a.nodeBits+a.sequenceBits > 12 {
-		return nil, errors.New("the node bits and sequence bits cannot be greater than 12")
+	// 这里强制检查缺省的41 bit timestamp之外只有53-41=12 bit, 兼容其他实现的宽id最多63 bit
+	maxBits := 53
+	if a.wide {
+		maxBits = 63
+	}
+	// 以int求和, uint8会溢出回绕
+	if int(a.timestampBits)+int(a.eraBits)+int(a.envBits)+int(a.nodeBits)+int(a.sequenceBits) > maxBits {
+		return nil, fmt.Errorf("the timestamp bits, era bits, environment bits, node bits and sequence bits cannot be greater than %d", maxBits)
 	}
 
-	// 计算max值
-	a.maxNode = 1<<a.nodeBits - 1
-	a.maxSequence = 1<<a.sequenceBits - 1
+	if err := a.setupUnit(); err != nil {
+		return nil, err
+	}
 
-	// 计算位移值
-	a.nodeMoveLength = a.sequenceBits
-	a.timestampMoveLength = a.sequenceBits + a.nodeBits
+	a.setupLayout()
+
+	if err := a.setupSequence(); err != nil {
+		return nil, err
+	}
+
+	if a.obfuscationKey != nil {
+		a.obfuscator = newObfuscator(a.obfuscationKey, a.timestampBits+a.eraBits+a.envBits+a.nodeBits+a.sequenceBits)
+	}
+
+	if a.priorityReserve >= a.maxSequence {
+		return nil, fmt.Errorf("the priority reserve must be less than %d", a.maxSequence)
+	}
+
+	if a.sequenceStart >= a.maxSequence-a.priorityReserve {
+		return nil, fmt.Errorf("the sequence start must be less than %d", a.maxSequence-a.priorityReserve)
+	}
+
+	// 划分datacenter时node id为datacenter内的worker id
+	if a.datacenterBits >= a.nodeBits {
+		return nil, errors.New("the datacenter bits must be less than the node bits")
+	}
+	if a.datacenterId >= 1<<a.datacenterBits {
+		return nil, fmt.Errorf("the datacenter id cannot be greater than %d", 1<<a.datacenterBits-1)
+	}
+	a.workerBits = a.nodeBits - a.datacenterBits
+
+	if a.nodeIdProvider != nil {
+		var err error
+		if nodeId, err = a.nodeIdProvider.NodeID(a.maxWorker()); err != nil {
+			return nil, err
+		}
+	}
 
 	if err := a.setupNodeId(nodeId); err != nil {
 		return nil, err
 	}
 
+	if a.machineChecker != nil {
+		if err := a.machineChecker.setup(a); err != nil {
+			return nil, err
+		}
+	}
+
+	if a.mmapState != nil {
+		// 持久化的毫秒之前的sequence视为已用完, 时钟落后时NextID会等到时钟追上
+		a.restoreMillis(atomic.LoadInt64(a.mmapState))
+	}
+
+	if a.stateFile != nil {
+		a.stateFile.restore(a)
+	}
+
 	return a, nil
 }
 
 // NextID generate snowflake id and return an error.
 // This function is thread safe.
 func (a *Algorithm) NextID() (uint64, error) {
-	c := currentMillis()
+	return a.nextID(a.maxSequence - a.priorityReserve)
+}
 
-	seq, err := a.atomicSequenceResolver(c)
+// MustNextID generate snowflake id like NextID, but panics on error,
+// e.g. for struct initializers.
+// This function is thread safe.
+func (a *Algorithm) MustNextID() uint64 {
+	id, err := a.NextID()
 	if err != nil {
-		return 0, err
+		panic(err)
+	}
+	return id
+}
+
+// NextIDPriority generate snowflake id like NextID, but it can also use the sequences
+// reserved by WithPriorityReserve, so it is not starved by the normal callers.
+// This function is thread safe.
+func (a *Algorithm) NextIDPriority() (uint64, error) {
+	return a.nextID(a.maxSequence)
+}
+
+// nextID generate snowflake id whose sequence is less than limit,
+// the ids rejected by the decimal filters are skipped, at most maxSkippedIDs in a row.
+func (a *Algorithm) nextID(limit uint32) (uint64, error) {
+	var start time.Time
+	if a.metrics != nil {
+		start = time.Now()
 	}
 
-	for seq >= a.maxSequence {
-		c = waitForNextMillis(c)
-		seq, err = a.atomicSequenceResolver(c)
+	for range maxSkippedIDs {
+		id, err := a.generate(limit)
 		if err != nil {
 			return 0, err
 		}
+
+		id = a.obfuscate(id)
+		if !a.skipped(id) {
+			a.stats.issued.Add(1)
+			if a.metrics != nil {
+				a.metrics.Issued(time.Since(start))
+			}
+			if a.events.active() {
+				a.events.publish(Event{Type: EventIssued, IDs: []uint64{id}})
+			}
+			return id, nil
+		}
+	}
+	return 0, ErrSkipExhausted
+}
+
+// skipped check whether the decimal form of id is rejected by the decimal filters.
+func (a *Algorithm) skipped(id uint64) bool {
+	if len(a.decimalFilters) == 0 {
+		return false
+	}
+
+	s := strconv.FormatUint(id, 10)
+	for _, skip := range a.decimalFilters {
+		if skip(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// generate compose snowflake id whose sequence is less than limit.
+func (a *Algorithm) generate(limit uint32) (uint64, error) {
+	c, seq, err := a.resolve(limit)
+	if err != nil {
+		return 0, err
+	}
+
+	df := a.elapsed(c)
+	if df < 0 || uint64(df) > a.maxElapsed {
+		return 0, ErrLifetimeExceeded
 	}
 
-	df := elapsedTime(c, a.startTime)
-	if df < 0 || uint64(df) > maxTimestamp {
-		return 0, errors.New("the maximum life cycle of the snowflake algorithm is 2^41-1(millis), please check starttime")
+	if a.lifetimeWarning != nil {
+		a.lifetimeWarning.check(a, uint64(df))
 	}
 
-	id := uint64(df)<<a.timestampMoveLength | a.nodeId<<a.nodeMoveLength | uint64(seq)
+	id := a.compose(uint64(df), seq)
+	if a.strictMonotonic {
+		return a.monotonic(id)
+	}
 	return id, nil
 }
 
+// resolve decide the millisecond and the sequence of the next id, the sequence is less than limit.
+// In replay mode the decisions are taken from the replayed timeline instead.
+func (a *Algorithm) resolve(limit uint32) (int64, uint32, error) {
+	if a.replay != nil {
+		return a.replay.next()
+	}
+
+	if a.machineChecker != nil {
+		if err := a.machineChecker.verify(a); err != nil {
+			return 0, 0, err
+		}
+	}
+
+	c := a.currentTick()
+
+	seq, err := a.sequence.Resolve(c, a.sequenceStart, limit)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if seq >= limit && (a.events.active() || a.metrics != nil) {
+		if last, _ := a.sequence.Last(); last > c+a.spillAhead() && !a.leaseWait(c, last) {
+			drift := time.Duration(last-c) * a.precision
+			if a.metrics != nil {
+				a.metrics.ClockBackwards(drift)
+			}
+			if a.events.active() {
+				a.events.publish(Event{Type: EventClockBackwards, Drift: drift})
+			}
+		}
+	}
+
+	// 时钟回拨不计入用完的tick
+	if last, _ := a.sequence.Last(); seq >= limit && last == c {
+		a.stats.exhaust(c)
+	}
+
+	// sequence用完和时钟回拨的等待分别统计
+	var exhaustedWait, backwardsWait, leaseWait time.Duration
+	exhaustedWaited, backwardsWaited := false, false
+	spilled := false
+	for seq >= limit {
+		now := a.currentTick()
+		last, _ := a.sequence.Last()
+		switch {
+		case last > now+a.spillAhead() && a.leaseWait(now, last):
+			// 等待状态文件恢复的租约过去, 不是时钟回拨
+			start := time.Now()
+			c = a.waitForNextTick(last)
+			leaseWait += time.Since(start)
+		case last > now+a.spillAhead():
+			// 时钟回拨
+			start := time.Now()
+			if c, err = a.onClockBackwards(now, last, limit); err != nil {
+				return 0, 0, err
+			}
+			if a.clockPolicy == ClockBackwardsWait {
+				backwardsWait += time.Since(start)
+				backwardsWaited = true
+			}
+		case a.overflowPolicy == OverflowError:
+			return 0, 0, ErrSequenceExhausted
+		case a.overflowPolicy == OverflowSpillNextMillis && !spilled:
+			// 借用下一个tick的sequence
+			c = now + a.granularity
+			spilled = true
+		default:
+			start := time.Now()
+			c = a.waitForNextTick(c)
+			exhaustedWait += time.Since(start)
+			exhaustedWaited = true
+		}
+
+		seq, err = a.sequence.Resolve(c, a.sequenceStart, limit)
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+
+	if exhaustedWaited || backwardsWaited || leaseWait > 0 {
+		wait := exhaustedWait + backwardsWait + leaseWait
+		a.stats.wait.Add(int64(wait))
+		if a.metrics != nil {
+			if exhaustedWaited {
+				a.metrics.Waited(exhaustedWait)
+			}
+			if backwardsWaited {
+				a.metrics.ClockBackwardsWaited(backwardsWait)
+			}
+		}
+		if a.events.active() {
+			a.events.publish(Event{Type: EventWait, Wait: wait})
+		}
+	}
+
+	if a.recorder != nil {
+		a.recorder.record(c, seq)
+	}
+
+	if a.mmapState != nil {
+		persistMillis(a.mmapState, c)
+	}
+
+	if a.stateFile != nil {
+		if err = a.stateFile.reserve(c); err != nil {
+			return 0, 0, err
+		}
+	}
+	return c, seq, nil
+}
+
+// onClockBackwards handle the clock going backwards from the last used tick to now
+// according to the clock backwards policy, it returns the tick to resolve the sequence with.
+func (a *Algorithm) onClockBackwards(now, last int64, limit uint32) (int64, error) {
+	if a.clockPolicy == ClockBackwardsError {
+		return 0, ErrClockBackwards
+	}
+
+	if drift := time.Duration(last-now) * a.precision; a.clockTolerance > 0 && drift > a.clockTolerance {
+		return 0, fmt.Errorf("%w: %v exceeds the tolerance", ErrClockBackwards, drift)
+	}
+
+	if a.clockPolicy == ClockBackwardsBorrow {
+		// 逻辑时钟: 继续使用最后的tick, 其sequence用完后借用下一个tick
+		if _, seq := a.sequence.Last(); seq+1 >= limit {
+			return last + a.granularity, nil
+		}
+		return last, nil
+	}
+
+	a.waitUntilMillis(last)
+	return a.currentTick(), nil
+}
+
+// PeekNextID returns the id NextID would issue next without consuming the sequence state.
+// It is a preview only: a concurrent caller may take the id, or the clock may move on,
+// before NextID is called.
+func (a *Algorithm) PeekNextID() (uint64, error) {
+	limit := a.maxSequence - a.priorityReserve
+
+	var c int64
+	var seq uint32
+	if a.replay != nil {
+		var err error
+		if c, seq, err = a.replay.peek(); err != nil {
+			return 0, err
+		}
+	} else {
+		c = a.currentTick()
+		seq = a.sequenceStart
+		// 时钟回拨时NextID会等待到最后发放id的毫秒
+		if last, lastSeq := a.sequence.Last(); last >= c {
+			c = last
+			seq = lastSeq + 1
+		}
+	}
+
+	if seq >= limit {
+		c += a.granularity
+		seq = a.sequenceStart
+	}
+
+	df := a.elapsed(c)
+	if df < 0 || uint64(df) > a.maxElapsed {
+		return 0, ErrLifetimeExceeded
+	}
+
+	var err error
+	id := a.compose(uint64(df), seq)
+	if hw := a.highWater.Load(); a.strictMonotonic && id <= hw {
+		if id, err = a.successor(hw); err != nil {
+			return 0, err
+		}
+	}
+
+	for skipped := 0; a.skipped(a.obfuscate(id)); skipped++ {
+		if skipped == maxSkippedIDs {
+			return 0, ErrSkipExhausted
+		}
+		if id, err = a.successor(id); err != nil {
+			return 0, err
+		}
+	}
+	return a.obfuscate(id), nil
+}
+
+// NextInt64 generate snowflake id as int64 like NextID, for the compatibility with
+// the implementations using int64 ids, e.g. bwmarrin/snowflake.
+// This function is thread safe.
+func (a *Algorithm) NextInt64() (int64, error) {
+	id, err := a.NextID()
+	if err != nil {
+		return 0, err
+	}
+	return int64(id), nil
+}
+
+// NextIDForKey generate snowflake id which is greater than any id returned before for the same key.
+// Ids of different keys may interleave with each other.
+// This function is thread safe.
+func (a *Algorithm) NextIDForKey(key string) (uint64, error) {
+	v, _ := a.keys.LoadOrStore(key, new(uint64))
+	last := v.(*uint64)
+
+	for {
+		id, err := a.NextID()
+		if err != nil {
+			return 0, err
+		}
+
+		// 混淆后的id无序, 比较原始id
+		raw := a.deobfuscate(id)
+		hw := atomic.LoadUint64(last)
+		if raw > hw {
+			if atomic.CompareAndSwapUint64(last, hw, raw) {
+				return id, nil
+			}
+			continue
+		}
+
+		// 时钟回拨, 等到时钟追上该key最后发放id的时间
+		if ts := hw >> a.timestampMoveLength; raw>>a.timestampMoveLength < ts {
+			a.waitUntilMillis(a.epochTicks() + int64(ts)*a.unit)
+		}
+	}
+}
+
+// ForgetKey remove the ordering state kept for the key by NextIDForKey.
+func (a *Algorithm) ForgetKey(key string) {
+	a.keys.Delete(key)
+}
+
 // Parse snowflake id to ID struct.
+// If legacy layouts are configured by WithLegacyLayouts and id is not plausible
+// for the algorithm, the first legacy layout id is plausible for is used instead.
 func (a *Algorithm) Parse(id uint64) ID {
+	parsed := a.parse(id)
+	if len(a.legacy) == 0 || a.plausible(parsed) {
+		return parsed
+	}
+
+	for _, l := range a.legacy {
+		if p := l.parse(id); l.plausible(p) {
+			return p
+		}
+	}
+	return parsed
+}
+
+// ParseStrict parse snowflake id to ID struct like Parse, but returns an error instead of
+// decoding garbage if id could not have been generated with the layout of the algorithm:
+// it has bits beyond the layout, its time is in the future, or its node is invalid.
+// The legacy layouts configured by WithLegacyLayouts are not considered.
+func (a *Algorithm) ParseStrict(id uint64) (ID, error) {
+	if a.deobfuscate(id)>>a.timestampMoveLength > a.maxElapsed {
+		return ID{}, fmt.Errorf("invalid snowflake id %d: bits beyond the layout", id)
+	}
+
+	parsed := a.parse(id)
+	if parsed.GetTime().After(a.clock.Now().Add(plausibleClockSkew)) {
+		return ID{}, fmt.Errorf("invalid snowflake id %d: time in the future", id)
+	}
+
+	if parsed.Worker == 0 && !a.zeroNode {
+		return ID{}, fmt.Errorf("invalid snowflake id %d: invalid node", id)
+	}
+	return parsed, nil
+}
+
+// plausible check whether the parsed id could have been generated by the algorithm:
+// its environment code matches and its time is not in the future.
+func (a *Algorithm) plausible(parsed ID) bool {
+	if parsed.Environment != a.envCode {
+		return false
+	}
+	return !parsed.GetTime().After(a.clock.Now().Add(plausibleClockSkew))
+}
+
+// parse decompose the public id, the id of the result is kept as the public id.
+func (a *Algorithm) parse(id uint64) ID {
+	parsed := a.decompose(a.deobfuscate(id))
+	parsed.id = id
+	return parsed
+}
+
+// decompose split id into fields by the layout of the algorithm.
+func (a *Algorithm) decompose(id uint64) ID {
+	era := id >> (a.timestampMoveLength + a.timestampBits)
+	node := id >> a.nodeMoveLength & uint64(a.maxNode)
 	return ID{
-		startTime: a.startTime,
-		Sequence:  id & uint64(a.maxSequence),
-		Node:      (id & (uint64(a.maxNode) << a.sequenceBits)) >> a.sequenceBits,
-		Timestamp: id >> uint64(a.timestampMoveLength),
+		id:          id,
+		startTime:   a.EraStart(era),
+		precision:   a.precision,
+		unit:        a.unit,
+		Sequence:    id >> a.sequenceMoveLength & uint64(a.maxSequence),
+		Node:        node,
+		Datacenter:  node >> a.workerBits,
+		Worker:      node & uint64(a.maxWorker()),
+		Environment: id >> a.envMoveLength & (1<<a.envBits - 1),
+		Era:         era,
+		Timestamp:   id >> a.timestampMoveLength & a.maxTimestamp,
+	}
+}
+
+// Era returns the current era of the algorithm, it is always 0 if era bits are not configured.
+func (a *Algorithm) Era() uint64 {
+	df := a.elapsed(a.millis())
+	if df < 0 {
+		return 0
+	}
+	return uint64(df) >> a.timestampBits
+}
+
+// EraStart returns the epoch of era, each era lasts 2^timestamp bits time units.
+func (a *Algorithm) EraStart(era uint64) time.Time {
+	return tickTime(a.epochTicks()+int64(era<<a.timestampBits)*a.unit, a.precision)
+}
+
+// NodeID returns the node id of the algorithm, including the datacenter bits.
+func (a *Algorithm) NodeID() uint64 {
+	return a.nodeId
+}
+
+// Layout returns the timestamp bits, node bits, sequence bits and precision of the algorithm,
+// the era bits and environment bits are not included.
+func (a *Algorithm) Layout() Layout {
+	l := Layout{TimestampBits: a.timestampBits, NodeBits: a.nodeBits, SequenceBits: a.sequenceBits}
+	if a.precision != time.Millisecond {
+		l.Precision = a.precision
+	}
+	return l
+}
+
+// Epoch returns the start time of the algorithm.
+func (a *Algorithm) Epoch() time.Time {
+	return a.startTime
+}
+
+// MaxSequencePerTick returns how many ids NextID can issue per timestamp tick, the sequences
+// reserved by WithPriorityReserve and skipped by WithSequenceStart are not included.
+func (a *Algorithm) MaxSequencePerTick() uint32 {
+	return a.maxSequence - a.priorityReserve - a.sequenceStart
+}
+
+// LifetimeEnd returns the time NextID starts returning ErrLifetimeExceeded, including the eras.
+func (a *Algorithm) LifetimeEnd() time.Time {
+	return tickTime(a.epochTicks()+int64(a.maxElapsed+1)*a.unit, a.precision)
+}
+
+// setupSequence create the default lock-free sequence resolver, its state packs the tick and
+// the sequence into one word, the mutex resolver is used if the ticks of the layout don't fit.
+func (a *Algorithm) setupSequence() error {
+	if a.sequence != nil {
+		if a.sharedState != nil {
+			return errors.New("the shared state cannot be used with a custom sequence resolver")
+		}
+		return nil
+	}
+
+	state := a.sharedState
+	if state == nil {
+		state = new(uint64)
+	}
+	r := newAtomicSequenceResolver(state, a.sequenceBits)
+
+	// 粒度内借用的下一个tick也要能表示
+	end := new(big.Int).Mul(new(big.Int).SetUint64(a.maxElapsed+1), big.NewInt(a.unit))
+	end.Add(end, big.NewInt(a.epochTicks()+a.granularity))
+	if end.Cmp(big.NewInt(r.maxTick)) > 0 {
+		if a.sharedState != nil {
+			return errors.New("the timestamp and sequence bits of the layout are too wide for the shared state")
+		}
+		a.sequence = NewMutexSequenceResolver()
+		return nil
 	}
+
+	a.sequence = r
+	return nil
+}
+
+// compose snowflake id from the elapsed timestamp and the sequence.
+func (a *Algorithm) compose(ts uint64, seq uint32) uint64 {
+	return ts<<a.timestampMoveLength | a.envCode<<a.envMoveLength | a.nodeId<<a.nodeMoveLength | uint64(seq)<<a.sequenceMoveLength
+}
+
+// monotonic make sure id is greater than any id returned before by this instance.
+// If the clock was adjusted backwards, the high-water mark's timestamp is reused and
+// its sequence is increased, so the node bits are kept untouched.
+func (a *Algorithm) monotonic(id uint64) (uint64, error) {
+	for {
+		hw := a.highWater.Load()
+		next := id
+		if next <= hw {
+			var err error
+			if next, err = a.successor(hw); err != nil {
+				return 0, err
+			}
+		}
+
+		if a.highWater.CompareAndSwap(hw, next) {
+			return next, nil
+		}
+	}
+}
+
+// successor returns the smallest id greater than id which has the same node.
+func (a *Algorithm) successor(id uint64) (uint64, error) {
+	if id>>a.sequenceMoveLength&uint64(a.maxSequence) < uint64(a.maxSequence) {
+		return id + 1<<a.sequenceMoveLength, nil
+	}
+
+	ts := id>>a.timestampMoveLength + 1
+	if ts > a.maxElapsed {
+		return 0, ErrLifetimeExceeded
+	}
+	return a.compose(ts, a.sequenceStart), nil
+}
+
+// setupUnit convert the time unit and the granularity set in milliseconds to ticks of the precision.
+func (a *Algorithm) setupUnit() error {
+	scale := int64(time.Millisecond / a.precision)
+	if a.unit == 0 {
+		a.unit = 1
+	} else {
+		a.unit *= scale
+	}
+
+	// 取整的粒度至少为一个时间单位
+	if a.granularity == 0 {
+		a.granularity = a.unit
+	} else {
+		a.granularity *= scale
+	}
+	if a.granularity%a.unit != 0 {
+		return errors.New("the timestamp granularity must be a multiple of the time unit")
+	}
+	return nil
 }
 
 func (a *Algorithm) setupNodeId(nodeId uint64) error {
-	if nodeId == 0 {
+	if nodeId == 0 && !a.zeroNode {
 		return errors.New("invalid node id")
 	}
 
 	// 有可能多个服务运行snowflake服务，但defaultNodeBits有限, nodeNumber不能大于nodeMax
-	if nodeId > uint64(a.maxNode) {
-		return fmt.Errorf("the nodeId cannot be greater than %d", a.maxNode)
+	if nodeId > uint64(a.maxWorker()) {
+		return fmt.Errorf("the nodeId cannot be greater than %d", a.maxWorker())
 	}
 
-	a.nodeId = nodeId
+	a.nodeId = a.datacenterId<<a.workerBits | nodeId
 	return nil
 }
 
+// setupLayout compute the max values and the shifts from the bits.
+func (a *Algorithm) setupLayout() {
+	// era位紧邻timestamp之上, 相当于扩展了timestamp的位数
+	a.maxTimestamp = 1<<a.timestampBits - 1
+	a.maxElapsed = 1<<(a.timestampBits+a.eraBits) - 1
+
+	// 计算max值
+	a.maxNode = 1<<a.nodeBits - 1
+	a.maxSequence = 1<<a.sequenceBits - 1
+
+	// 计算位移值
+	a.nodeMoveLength = a.sequenceBits
+	if a.sequenceHigh {
+		a.sequenceMoveLength = a.nodeBits
+		a.nodeMoveLength = 0
+	}
+	a.envMoveLength = a.sequenceBits + a.nodeBits
+	a.timestampMoveLength = a.sequenceBits + a.nodeBits + a.envBits
+}
+
+// maxWorker returns the max worker id in the datacenter, it is the max node id without datacenter bits.
+func (a *Algorithm) maxWorker() uint32 {
+	return 1<<a.workerBits - 1
+}
+
 //--------------------------------------------------------------------
 // private function defined.
 //--------------------------------------------------------------------
 
-func waitForNextMillis(last int64) int64 {
-	now := currentMillis()
-	for now == last {
-		now = currentMillis()
+// currentTick get current tick rounded down to the timestamp granularity.
+func (a *Algorithm) currentTick() int64 {
+	ms := a.millis()
+	return ms - ms%a.granularity
+}
+
+// waitForNextTick wait until the tick after last.
+func (a *Algorithm) waitForNextTick(last int64) int64 {
+	if a.granularity == 1 {
+		return a.waitForNextMillis(last)
 	}
-	return now
+
+	a.waitUntilMillis(last + a.granularity)
+	return a.currentTick()
 }
 
-func elapsedTime(noms int64, t time.Time) int64 {
-	return noms - t.UTC().UnixNano()/1e6
+// persistMillis store ms into state if it is greater than the stored one.
+func persistMillis(state *int64, ms int64) {
+	for {
+		last := atomic.LoadInt64(state)
+		if last >= ms || atomic.CompareAndSwapInt64(state, last, ms) {
+			return
+		}
+	}
 }
 
-// currentMillis get current millisecond.
-func currentMillis() int64 {
-	return time.Now().UTC().UnixNano() / 1e6
+// restoreMillis mark the sequences up to ms as used, so no id is issued before or at ms.
+func (a *Algorithm) restoreMillis(ms int64) {
+	a.sequence.Restore(ms, a.maxSequence)
 }
 
-// When you want to use the snowflake algorithm to generate unique ID, You must ensure: The sequence-number generated in the same millisecond of the same node is unique.
-// Based on this, we create this interface provide following resolver:
-// atomicSequenceResolver define as atomic sequence resolver, base on standard sync/atomic.
-func (a *Algorithm) atomicSequenceResolver(ms int64) (uint32, error) {
-	var last int64
-	var seq, localSeq uint32
+func (a *Algorithm) waitUntilMillis(ms int64) {
+	if df := ms - a.millis(); df > 0 {
+		time.Sleep(time.Duration(df) * a.precision)
+	}
+}
 
-	for {
-		last = atomic.LoadInt64(&lastTime)
-		localSeq = atomic.LoadUint32(&lastSeq)
-		if last > ms {
-			return a.maxSequence, nil
-		}
+// elapsed get the time units elapsed from the start time to ms, it is negative if ms is before the start time.
+func (a *Algorithm) elapsed(ms int64) int64 {
+	df := ms - a.epochTicks()
+	if df < 0 {
+		return df
+	}
+	return df / a.unit
+}
 
-		if last == ms {
-			seq = a.maxSequence & (localSeq + 1)
-			if seq == 0 {
-				return a.maxSequence, nil
-			}
-		}
+// epochTicks get the start time in ticks of the precision since the unix epoch.
+func (a *Algorithm) epochTicks() int64 {
+	return a.startTime.UnixNano() / int64(a.precision)
+}
 
-		if atomic.CompareAndSwapInt64(&lastTime, last, ms) && atomic.CompareAndSwapUint32(&lastSeq, localSeq, seq) {
-			return seq, nil
-		}
+// tickTime convert ticks of the precision since the unix epoch to time.Time.
+func tickTime(ticks int64, precision time.Duration) time.Time {
+	if precision == time.Microsecond {
+		return time.UnixMicro(ticks).UTC()
 	}
+	return time.UnixMilli(ticks).UTC()
+}
+
+func elapsedTime(noms int64, t time.Time) int64 {
+	return noms - t.UTC().UnixNano()/1e6
+}
+
+// millis get current tick from the clock of the algorithm, the millisecond by default.
+func (a *Algorithm) millis() int64 {
+	return a.clock.Now().UnixNano() / int64(a.precision)
+}
+
+// currentMillis get current millisecond.
+func currentMillis() int64 {
+	return time.Now().UTC().UnixNano() / 1e6
 }
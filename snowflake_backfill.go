@@ -0,0 +1,202 @@
+package snowflake
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Backfill mints ids with historical timestamps, e.g. when importing legacy data into a
+// snowflake-keyed table. It must use a node id reserved for backfilling which no live
+// generator uses, so the minted ids never collide with live traffic.
+//
+// The next sequence of every used millisecond is kept in a cursor file, each minted id
+// is appended to it before returning, so restarting a backfill never reissues ids.
+// The file is compacted to one line per millisecond on open and whenever the appended
+// lines outnumber the milliseconds. On unix systems a "<cursor>.lock" file is locked while
+// the backfill is open, so two backfills cannot use the same cursor file at the same time.
+type Backfill struct {
+	a      *Algorithm
+	mu     sync.Mutex
+	path   string
+	lock   *os.File
+	file   *os.File
+	lines  int
+	cursor map[int64]uint32 // unix millisecond -> next sequence
+}
+
+// NewBackfill create a backfill generator with the reserved node id and the cursor file,
+// the options are the same as New and should match the live generators' layout.
+func NewBackfill(nodeId uint64, cursorPath string, options ...Option) (*Backfill, error) {
+	a, err := New(nodeId, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	lock, err := os.OpenFile(cursorPath+".lock", os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	if err = tryLockFile(lock); err != nil {
+		lock.Close()
+		if errors.Is(err, errFileLocked) {
+			return nil, fmt.Errorf("the backfill cursor %s is used by another backfill", cursorPath)
+		}
+		return nil, err
+	}
+
+	cursor, err := loadBackfillCursor(cursorPath)
+	if err != nil {
+		lock.Close()
+		return nil, err
+	}
+
+	b := &Backfill{a: a, path: cursorPath, lock: lock, cursor: cursor}
+	if err = b.compact(); err != nil {
+		lock.Close()
+		return nil, err
+	}
+	return b, nil
+}
+
+// NextIDAt mints an id whose timestamp is t, t must be in the past.
+// This function is thread safe.
+func (b *Backfill) NextIDAt(t time.Time) (uint64, error) {
+	if !t.Before(b.a.clock.Now()) {
+		return 0, errors.New("the backfill time must be in the past")
+	}
+
+	ms := t.UnixNano() / int64(b.a.precision)
+	df := b.a.elapsed(ms)
+	if df < 0 || uint64(df) > b.a.maxElapsed {
+		return 0, ErrLifetimeExceeded
+	}
+	// 同一时间单位内的id共用一个cursor
+	ms = b.a.epochTicks() + df*b.a.unit
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	seq, exists := b.cursor[ms]
+	if !exists {
+		seq = b.a.sequenceStart
+	}
+	if seq >= b.a.maxSequence {
+		return 0, fmt.Errorf("the sequences of %s are used up", t.UTC().Format(time.RFC3339Nano))
+	}
+
+	if _, err := fmt.Fprintf(b.file, "%d %d\n", ms, seq+1); err != nil {
+		return 0, err
+	}
+	b.cursor[ms] = seq + 1
+	b.lines++
+
+	// 每毫秒只需保留最后一行
+	if b.lines > 2*len(b.cursor)+backfillCompactLines {
+		if err := b.compact(); err != nil {
+			return 0, err
+		}
+	}
+
+	return b.a.obfuscate(b.a.compose(uint64(df), seq)), nil
+}
+
+// Close close the cursor file and release its lock.
+func (b *Backfill) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	err := b.file.Close()
+	if lockErr := b.lock.Close(); err == nil {
+		err = lockErr
+	}
+	return err
+}
+
+// backfillCompactLines is the number of the redundant lines tolerated in the cursor file.
+const backfillCompactLines = 1024
+
+// compact rewrite the cursor file with one line per millisecond and reopen it for appending.
+// The new file is written aside and renamed over the old one, so a crash keeps either of them.
+func (b *Backfill) compact() error {
+	if b.file != nil {
+		if err := b.file.Close(); err != nil {
+			return err
+		}
+		b.file = nil
+	}
+
+	tmp := b.path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(f)
+	for ms, seq := range b.cursor {
+		fmt.Fprintf(w, "%d %d\n", ms, seq)
+	}
+	if err = w.Flush(); err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp, b.path)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	if b.file, err = os.OpenFile(b.path, os.O_WRONLY|os.O_APPEND, 0o644); err != nil {
+		return err
+	}
+	b.lines = len(b.cursor)
+	return nil
+}
+
+// loadBackfillCursor read the cursor file, each line is "<unix millisecond> <next sequence>".
+func loadBackfillCursor(path string) (map[int64]uint32, error) {
+	cursor := make(map[int64]uint32)
+
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return cursor, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("invalid backfill cursor at line %d", line)
+		}
+
+		ms, err := strconv.ParseInt(fields[0], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid backfill cursor at line %d: %w", line, err)
+		}
+		seq, err := strconv.ParseUint(fields[1], 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid backfill cursor at line %d: %w", line, err)
+		}
+
+		if uint32(seq) > cursor[ms] {
+			cursor[ms] = uint32(seq)
+		}
+	}
+	return cursor, scanner.Err()
+}
@@ -0,0 +1,61 @@
+package snowflake
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// WriteIDs write ids to w in a compact form: the count of the ids followed by
+// the zigzag varint encoded delta of each id to the previous one.
+// Ids generated close in time have small deltas, so they take only a few bytes.
+func WriteIDs(w io.Writer, ids []uint64) error {
+	bw := bufio.NewWriter(w)
+	buf := make([]byte, binary.MaxVarintLen64)
+
+	n := binary.PutUvarint(buf, uint64(len(ids)))
+	if _, err := bw.Write(buf[:n]); err != nil {
+		return err
+	}
+
+	var prev uint64
+	for _, id := range ids {
+		n = binary.PutVarint(buf, int64(id-prev))
+		if _, err := bw.Write(buf[:n]); err != nil {
+			return err
+		}
+		prev = id
+	}
+	return bw.Flush()
+}
+
+// ReadIDs read the ids written by WriteIDs from r.
+// If r does not implement io.ByteReader it is buffered, and may be read past the end of the ids.
+func ReadIDs(r io.Reader) ([]uint64, error) {
+	br, ok := r.(io.ByteReader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+
+	count, err := binary.ReadUvarint(br)
+	if err != nil {
+		return nil, err
+	}
+
+	// 防止损坏的数据导致分配过大的内存
+	ids := make([]uint64, 0, min(count, 1<<16))
+	var prev uint64
+	for i := uint64(0); i < count; i++ {
+		delta, err := binary.ReadVarint(br)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+		prev += uint64(delta)
+		ids = append(ids, prev)
+	}
+	return ids, nil
+}
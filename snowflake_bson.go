@@ -0,0 +1,82 @@
+package snowflake
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// BSONEncoding define how ID is stored in MongoDB.
+type BSONEncoding int
+
+const (
+	BSONInt64  BSONEncoding = iota // stored as BSON int64
+	BSONString                     // stored as BSON string in decimal form
+)
+
+const (
+	bsonTypeString byte = 0x02
+	bsonTypeInt32  byte = 0x10
+	bsonTypeInt64  byte = 0x12
+)
+
+// DefaultBSONEncoding is the encoding used by ID.MarshalBSONValue, it should be set
+// before any ID is marshaled, recommended you set it in the main function.
+var DefaultBSONEncoding = BSONInt64
+
+// MarshalBSONValue implements the bson.ValueMarshaler interface of go.mongodb.org/mongo-driver/v2,
+// the id is stored as int64 or string according to DefaultBSONEncoding.
+func (i ID) MarshalBSONValue() (byte, []byte, error) {
+	if DefaultBSONEncoding == BSONString {
+		s := strconv.FormatUint(i.id, 10)
+		b := make([]byte, 4, 4+len(s)+1)
+		binary.LittleEndian.PutUint32(b, uint32(len(s)+1))
+		b = append(b, s...)
+		return bsonTypeString, append(b, 0), nil
+	}
+
+	if i.id > math.MaxInt64 {
+		return 0, nil, fmt.Errorf("snowflake id %d overflows int64", i.id)
+	}
+	return bsonTypeInt64, binary.LittleEndian.AppendUint64(nil, i.id), nil
+}
+
+// UnmarshalBSONValue implements the bson.ValueUnmarshaler interface of go.mongodb.org/mongo-driver/v2,
+// it accepts the id stored as int32, int64 or string whatever DefaultBSONEncoding is.
+func (i *ID) UnmarshalBSONValue(typ byte, data []byte) error {
+	var id uint64
+	switch typ {
+	case bsonTypeInt64:
+		if len(data) != 8 {
+			return fmt.Errorf("invalid bson int64 length: %d", len(data))
+		}
+		v := int64(binary.LittleEndian.Uint64(data))
+		if v < 0 {
+			return fmt.Errorf("invalid snowflake id: %d", v)
+		}
+		id = uint64(v)
+	case bsonTypeInt32:
+		if len(data) != 4 {
+			return fmt.Errorf("invalid bson int32 length: %d", len(data))
+		}
+		v := int32(binary.LittleEndian.Uint32(data))
+		if v < 0 {
+			return fmt.Errorf("invalid snowflake id: %d", v)
+		}
+		id = uint64(v)
+	case bsonTypeString:
+		if len(data) < 5 || int(binary.LittleEndian.Uint32(data)) != len(data)-4 {
+			return fmt.Errorf("invalid bson string length: %d", len(data))
+		}
+		var err error
+		if id, err = UnmarshalIDText(data[4 : len(data)-1]); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("cannot unmarshal bson type 0x%02x into snowflake id", typ)
+	}
+
+	*i = ID{id: id}
+	return nil
+}
@@ -0,0 +1,74 @@
+package snowflake
+
+import (
+	"sync"
+	"time"
+)
+
+// bufferRetryInterval is how long the filler of a BufferedGenerator backs off after an error.
+const bufferRetryInterval = time.Millisecond
+
+// BufferedGenerator pre-generates ids in a background goroutine, so NextID on the hot path is
+// a single channel receive. The buffered ids keep their generation time: they may be issued
+// a while after they were minted, and out of order with the ids generated directly.
+type BufferedGenerator struct {
+	a    *Algorithm
+	ids  chan uint64
+	done chan struct{}
+	once sync.Once
+}
+
+// Buffered create a BufferedGenerator keeping up to size ids generated by a.
+func Buffered(a *Algorithm, size int) *BufferedGenerator {
+	if size < 1 {
+		size = 1
+	}
+
+	b := &BufferedGenerator{
+		a:    a,
+		ids:  make(chan uint64, size),
+		done: make(chan struct{}),
+	}
+	go b.fill()
+	return b
+}
+
+// NextID returns a buffered id, it falls back to generating the id directly if the buffer
+// is drained, so the errors of the algorithm are returned as they are.
+// This function is thread safe.
+func (b *BufferedGenerator) NextID() (uint64, error) {
+	select {
+	case id := <-b.ids:
+		return id, nil
+	default:
+		return b.a.NextID()
+	}
+}
+
+// Close stop the background goroutine, the ids left in the buffer are discarded.
+func (b *BufferedGenerator) Close() {
+	b.once.Do(func() {
+		close(b.done)
+	})
+}
+
+func (b *BufferedGenerator) fill() {
+	for {
+		id, err := b.a.NextID()
+		if err != nil {
+			// 错误由直接生成的NextID返回, 这里稍后重试
+			select {
+			case <-b.done:
+				return
+			case <-time.After(bufferRetryInterval):
+			}
+			continue
+		}
+
+		select {
+		case <-b.done:
+			return
+		case b.ids <- id:
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package snowflake
+
+// the alphabets used by github.com/bwmarrin/snowflake
+const (
+	bwmarrinBase58Alphabet = "123456789abcdefghijkmnopqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ"
+	bwmarrinBase32Alphabet = "ybndrfg8ejkmcpqxot1uwisza345h769"
+)
+
+// FormatBwmarrinBase58 returns the base58 form of id used by bwmarrin/snowflake's ID.Base58.
+func FormatBwmarrinBase58(id uint64) string {
+	return formatBase(id, 0, bwmarrinBase58Alphabet)
+}
+
+// ParseBwmarrinBase58 parse the base58 form produced by bwmarrin/snowflake's ID.Base58.
+func ParseBwmarrinBase58(s string) (uint64, error) {
+	return parseBase(s, bwmarrinBase58Alphabet)
+}
+
+// FormatBwmarrinBase32 returns the z-base-32 form of id used by bwmarrin/snowflake's ID.Base32.
+func FormatBwmarrinBase32(id uint64) string {
+	return formatBase(id, 0, bwmarrinBase32Alphabet)
+}
+
+// ParseBwmarrinBase32 parse the z-base-32 form produced by bwmarrin/snowflake's ID.Base32.
+func ParseBwmarrinBase32(s string) (uint64, error) {
+	return parseBase(s, bwmarrinBase32Alphabet)
+}
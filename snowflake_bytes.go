@@ -0,0 +1,66 @@
+package snowflake
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// PutBytesBE write id into dst in big-endian byte order.
+// It panics if dst is shorter than 8 bytes.
+func PutBytesBE(dst []byte, id uint64) {
+	binary.BigEndian.PutUint64(dst, id)
+}
+
+// PutBytesLE write id into dst in little-endian byte order.
+// It panics if dst is shorter than 8 bytes.
+func PutBytesLE(dst []byte, id uint64) {
+	binary.LittleEndian.PutUint64(dst, id)
+}
+
+// ReadBytesBE read id written by PutBytesBE from src.
+// It panics if src is shorter than 8 bytes.
+func ReadBytesBE(src []byte) uint64 {
+	return binary.BigEndian.Uint64(src)
+}
+
+// ReadBytesLE read id written by PutBytesLE from src.
+// It panics if src is shorter than 8 bytes.
+func ReadBytesLE(src []byte) uint64 {
+	return binary.LittleEndian.Uint64(src)
+}
+
+// Key returns the id as an 8 bytes big-endian key, the keys sort lexicographically
+// by generation time, so range scans by creation time work directly on the key bytes
+// in KV stores like BoltDB, Badger or RocksDB.
+func (i ID) Key() [8]byte {
+	var k [8]byte
+	binary.BigEndian.PutUint64(k[:], i.id)
+	return k
+}
+
+// FromKey returns the raw id of the key produced by Key.
+func FromKey(k [8]byte) uint64 {
+	return binary.BigEndian.Uint64(k[:])
+}
+
+// Bytes returns the id in 8 bytes big-endian byte order, the same as Key.
+func (i ID) Bytes() [8]byte {
+	return i.Key()
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler, the id is encoded in 8 bytes
+// big-endian byte order, preserving the sort order.
+func (i ID) MarshalBinary() ([]byte, error) {
+	k := i.Key()
+	return k[:], nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+func (i *ID) UnmarshalBinary(data []byte) error {
+	if len(data) != 8 {
+		return fmt.Errorf("invalid snowflake id: %d bytes", len(data))
+	}
+
+	*i = ID{id: binary.BigEndian.Uint64(data)}
+	return nil
+}
@@ -0,0 +1,56 @@
+package snowflake
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// MarshalCBOR implements the cbor.Marshaler interface of github.com/fxamacker/cbor,
+// the id is encoded as a CBOR unsigned integer in the shortest form (RFC 8949 preferred serialization).
+func (i ID) MarshalCBOR() ([]byte, error) {
+	switch id := i.id; {
+	case id < 24:
+		return []byte{byte(id)}, nil
+	case id <= 0xff:
+		return []byte{0x18, byte(id)}, nil
+	case id <= 0xffff:
+		return binary.BigEndian.AppendUint16([]byte{0x19}, uint16(id)), nil
+	case id <= 0xffffffff:
+		return binary.BigEndian.AppendUint32([]byte{0x1a}, uint32(id)), nil
+	default:
+		return binary.BigEndian.AppendUint64([]byte{0x1b}, id), nil
+	}
+}
+
+// UnmarshalCBOR implements the cbor.Unmarshaler interface of github.com/fxamacker/cbor,
+// it accepts a CBOR unsigned integer in any length.
+func (i *ID) UnmarshalCBOR(data []byte) error {
+	if len(data) == 0 {
+		return errors.New("invalid cbor snowflake id: empty data")
+	}
+
+	// major type 0: unsigned integer
+	if data[0]>>5 != 0 {
+		return fmt.Errorf("invalid cbor snowflake id: unexpected major type %d", data[0]>>5)
+	}
+
+	var id uint64
+	switch info, body := data[0]&0x1f, data[1:]; {
+	case info < 24 && len(body) == 0:
+		id = uint64(info)
+	case info == 24 && len(body) == 1:
+		id = uint64(body[0])
+	case info == 25 && len(body) == 2:
+		id = uint64(binary.BigEndian.Uint16(body))
+	case info == 26 && len(body) == 4:
+		id = uint64(binary.BigEndian.Uint32(body))
+	case info == 27 && len(body) == 8:
+		id = binary.BigEndian.Uint64(body)
+	default:
+		return errors.New("invalid cbor snowflake id: malformed unsigned integer")
+	}
+
+	*i = ID{id: id}
+	return nil
+}
@@ -0,0 +1,84 @@
+package snowflake
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrClockBackwards is returned when the clock moved backwards and the clock backwards policy
+// refuses to generate ids.
+var ErrClockBackwards = errors.New("the clock moved backwards")
+
+// ClockBackwardsPolicy define how the algorithm handles the clock moving backwards,
+// e.g. after an NTP step.
+type ClockBackwardsPolicy int
+
+const (
+	ClockBackwardsWait   ClockBackwardsPolicy = iota // wait until the clock catches up, the default policy
+	ClockBackwardsError                              // return ErrClockBackwards
+	ClockBackwardsBorrow                             // keep issuing ids from a logical clock following the last used timestamp
+)
+
+// WithClockBackwardsPolicy set how the algorithm handles the clock moving backwards.
+// If tolerance is positive, ErrClockBackwards is returned when the clock moved backwards
+// more than tolerance, instead of waiting for or borrowing that long.
+func WithClockBackwardsPolicy(policy ClockBackwardsPolicy, tolerance time.Duration) Option {
+	return func(a *Algorithm) error {
+		if policy < ClockBackwardsWait || policy > ClockBackwardsBorrow {
+			return errors.New("invalid clock backwards policy")
+		}
+
+		if tolerance < 0 {
+			return errors.New("the clock backwards tolerance cannot be negative")
+		}
+
+		a.clockPolicy = policy
+		a.clockTolerance = tolerance
+		return nil
+	}
+}
+
+// Clock is the time source of the algorithm, tests can freeze or step it to assert exact ids.
+type Clock interface {
+	Now() time.Time
+}
+
+// WithClock set the time source of the algorithm, default is the system clock.
+// NextID still sleeps in real time while waiting for the clock to advance,
+// a frozen clock must be stepped by another goroutine once the sequences are used up.
+func WithClock(clock Clock) Option {
+	return func(a *Algorithm) error {
+		if clock == nil {
+			return errors.New("invalid clock")
+		}
+
+		a.clock = clock
+		return nil
+	}
+}
+
+// WithMonotonicClock derive the timestamps from the wall time captured once by New plus the
+// monotonic time elapsed since, so NTP slews and steps of the wall clock can't distort or
+// rewind them. The timestamps drift from the wall clock as much as the uncorrected system
+// clock does, and the monotonic clock may not advance while the host is suspended.
+func WithMonotonicClock() Option {
+	return func(a *Algorithm) error {
+		a.clock = monotonicClock{start: time.Now()}
+		return nil
+	}
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+type monotonicClock struct {
+	start time.Time
+}
+
+// Now returns the wall time of start plus the monotonic time elapsed since.
+func (c monotonicClock) Now() time.Time {
+	return c.start.Add(time.Since(c.start))
+}
@@ -0,0 +1,112 @@
+package snowflake
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// The helpers below encode a raw snowflake id for JSON, text and database/sql.
+// The typed ids generated by cmd/snowflakegen delegate to them.
+
+// MarshalIDText returns the decimal form of id.
+func MarshalIDText(id uint64) ([]byte, error) {
+	return strconv.AppendUint(nil, id, 10), nil
+}
+
+// UnmarshalIDText parse the decimal form of id.
+func UnmarshalIDText(text []byte) (uint64, error) {
+	id, err := strconv.ParseUint(string(text), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid snowflake id %q: %w", text, err)
+	}
+	return id, nil
+}
+
+// MarshalIDJSON returns id as a JSON string, so it is not rounded by
+// JavaScript clients which parse JSON numbers as float64.
+func MarshalIDJSON(id uint64) ([]byte, error) {
+	b := make([]byte, 0, 22)
+	b = append(b, '"')
+	b = strconv.AppendUint(b, id, 10)
+	b = append(b, '"')
+	return b, nil
+}
+
+// UnmarshalIDJSON parse id from a JSON string or a JSON number.
+func UnmarshalIDJSON(data []byte) (uint64, error) {
+	if bytes.Equal(data, []byte("null")) {
+		return 0, nil
+	}
+
+	if len(data) >= 2 && data[0] == '"' && data[len(data)-1] == '"' {
+		data = data[1 : len(data)-1]
+	}
+	return UnmarshalIDText(data)
+}
+
+// ValueID returns id as an int64 database value, which fit the BIGINT columns.
+func ValueID(id uint64) (driver.Value, error) {
+	if id > math.MaxInt64 {
+		return nil, fmt.Errorf("snowflake id %d overflows int64", id)
+	}
+	return int64(id), nil
+}
+
+// ScanID read id from a database value stored as integer or string.
+func ScanID(src any) (uint64, error) {
+	switch v := src.(type) {
+	case int64:
+		if v < 0 {
+			return 0, fmt.Errorf("invalid snowflake id %d", v)
+		}
+		return uint64(v), nil
+	case uint64:
+		return v, nil
+	case []byte:
+		return UnmarshalIDText(v)
+	case string:
+		return UnmarshalIDText([]byte(v))
+	case nil:
+		return 0, errors.New("cannot scan NULL into snowflake id")
+	default:
+		return 0, fmt.Errorf("cannot scan %T into snowflake id", src)
+	}
+}
+
+// MarshalJSON implements json.Marshaler, the id is serialized as a decimal string,
+// so it survives a round trip through a browser untouched.
+func (i ID) MarshalJSON() ([]byte, error) {
+	return MarshalIDJSON(i.id)
+}
+
+// UnmarshalJSON implements json.Unmarshaler, it accepts the id as a string or a number.
+func (i *ID) UnmarshalJSON(data []byte) error {
+	id, err := UnmarshalIDJSON(data)
+	if err != nil {
+		return err
+	}
+
+	*i = ID{id: id}
+	return nil
+}
+
+// Value implements driver.Valuer, the id is stored as int64 which fits the BIGINT columns.
+func (i ID) Value() (driver.Value, error) {
+	return ValueID(i.id)
+}
+
+// Scan implements sql.Scanner, it reads the id stored as integer or string, e.g. BIGINT or VARCHAR.
+// Use sql.Null[ID] for nullable columns.
+func (i *ID) Scan(src any) error {
+	id, err := ScanID(src)
+	if err != nil {
+		return err
+	}
+
+	*i = ID{id: id}
+	return nil
+}
@@ -0,0 +1,51 @@
+package snowflake
+
+import (
+	"cmp"
+	"slices"
+)
+
+// Compare compare the parsed ids by the generation time, then the sequence and the node,
+// it returns -1 if i is before other, 1 if it is after and 0 if they are equal.
+// Unlike the raw ids, the parsed ids compare correctly across epochs and layouts,
+// both of them must be decomposed by Algorithm.Parse or Decompose.
+func (i ID) Compare(other ID) int {
+	if c := i.GetTime().Compare(other.GetTime()); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(i.Sequence, other.Sequence); c != 0 {
+		return c
+	}
+	return cmp.Compare(i.Node, other.Node)
+}
+
+// Before reports whether i was generated before other, see Compare.
+func (i ID) Before(other ID) bool {
+	return i.Compare(other) < 0
+}
+
+// After reports whether i was generated after other, see Compare.
+func (i ID) After(other ID) bool {
+	return i.Compare(other) > 0
+}
+
+// Compare compare the raw ids x and y generated by the algorithm like ID.Compare, the ids are
+// decoded with the layout, epoch and obfuscation of the algorithm.
+func (a *Algorithm) Compare(x, y uint64) int {
+	return a.Parse(x).Compare(a.Parse(y))
+}
+
+// SortIDs sort the raw ids in place by ID.Compare, parse decodes each id, e.g. Algorithm.Parse,
+// or a function picking the generator of the id by its source when the ids of several
+// layouts or epochs are mixed.
+func SortIDs(ids []uint64, parse func(uint64) ID) {
+	parsed := make([]ID, len(ids))
+	for i, id := range ids {
+		parsed[i] = parse(id)
+	}
+	slices.SortFunc(parsed, ID.Compare)
+
+	for i, id := range parsed {
+		ids[i] = id.id
+	}
+}
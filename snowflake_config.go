@@ -0,0 +1,82 @@
+package snowflake
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Config is the generator configuration for config-file-driven services, e.g. decoded from
+// YAML or JSON. The zero fields keep the defaults.
+type Config struct {
+	NodeID       uint64 `json:"nodeId" yaml:"nodeId"`
+	NodeBits     uint8  `json:"nodeBits,omitempty" yaml:"nodeBits,omitempty"`
+	SequenceBits uint8  `json:"sequenceBits,omitempty" yaml:"sequenceBits,omitempty"`
+	// Epoch is the start time in unix milliseconds
+	Epoch int64 `json:"epoch,omitempty" yaml:"epoch,omitempty"`
+	// TimeUnit is the duration of a timestamp unit, e.g. "10ms"
+	TimeUnit string `json:"timeUnit,omitempty" yaml:"timeUnit,omitempty"`
+}
+
+// NewFromConfig create the algorithm configured by cfg, the options are applied after it.
+func NewFromConfig(cfg Config, options ...Option) (*Algorithm, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return New(cfg.NodeID, append(cfg.options(), options...)...)
+}
+
+// Validate check the fields of the config, the error names the invalid field.
+func (cfg Config) Validate() error {
+	nodeBits := defaultNodeBits
+	if cfg.NodeBits != 0 {
+		nodeBits = cfg.NodeBits
+	}
+	if nodeBits > 10 {
+		return fmt.Errorf("invalid snowflake config: nodeBits %d is greater than 10", nodeBits)
+	}
+	if cfg.NodeID >= 1<<nodeBits {
+		return fmt.Errorf("invalid snowflake config: nodeId %d is greater than %d", cfg.NodeID, 1<<nodeBits-1)
+	}
+
+	if cfg.SequenceBits > 12 {
+		return fmt.Errorf("invalid snowflake config: sequenceBits %d is greater than 12", cfg.SequenceBits)
+	}
+
+	if cfg.Epoch < 0 || cfg.Epoch > currentMillis() {
+		return fmt.Errorf("invalid snowflake config: epoch %d is not between 1970 and now", cfg.Epoch)
+	}
+
+	if cfg.TimeUnit != "" {
+		unit, err := time.ParseDuration(cfg.TimeUnit)
+		if err != nil {
+			return fmt.Errorf("invalid snowflake config: timeUnit: %w", err)
+		}
+		if unit < time.Millisecond || unit%time.Millisecond != 0 {
+			return errors.New("invalid snowflake config: timeUnit must be a positive multiple of 1ms")
+		}
+	}
+	return nil
+}
+
+// options returns the options of the validated config.
+func (cfg Config) options() []Option {
+	var options []Option
+	if cfg.Epoch != 0 {
+		options = append(options, WithEpochMillis(cfg.Epoch))
+	}
+
+	if cfg.NodeBits != 0 {
+		options = append(options, WithNodeBits(cfg.NodeBits))
+	}
+
+	if cfg.SequenceBits != 0 {
+		options = append(options, WithSequenceBits(cfg.SequenceBits))
+	}
+
+	if cfg.TimeUnit != "" {
+		unit, _ := time.ParseDuration(cfg.TimeUnit)
+		options = append(options, WithTimeUnit(unit))
+	}
+	return options
+}
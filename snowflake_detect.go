@@ -0,0 +1,108 @@
+package snowflake
+
+import (
+	"sort"
+	"time"
+)
+
+// Candidate is a layout an id could have been generated with, returned by Detect.
+type Candidate struct {
+	Name         string // the preset name, or "custom" for a plausible bit split
+	Epoch        time.Time
+	NodeBits     uint8
+	SequenceBits uint8
+	Time         time.Time
+	Node         uint64
+	Sequence     uint64
+	Score        float64 // higher is more likely
+}
+
+var (
+	// defaultPreset is the default layout of this package.
+	defaultPreset = Preset{
+		Name:         "hdget",
+		Epoch:        defaultStartTime,
+		NodeBits:     defaultNodeBits,
+		SequenceBits: defaultSequenceBits,
+	}
+	// detectPresets are the presets Detect tests ids against.
+	detectPresets = []Preset{defaultPreset, PresetBwmarrin, PresetDiscord, PresetInstagram, PresetSonyflake}
+	// detectEpochs are the epochs Detect tries the plausible bit splits with.
+	detectEpochs = []time.Time{defaultStartTime, time.UnixMilli(0).UTC()}
+	// detectWindowStart is the lower bound of the realistic generation time window.
+	detectWindowStart = time.Date(2008, 1, 1, 0, 0, 0, 0, time.UTC)
+)
+
+// Detect test id against the known presets and the plausible bit splits, helping to
+// identify the format of ids from third-party systems. A layout is a candidate if the
+// decoded time falls in a realistic window, from 2008 to now. The candidates are sorted
+// by score, the presets and the recent times score higher.
+func Detect(id uint64) []Candidate {
+	now := time.Now().UTC()
+
+	var candidates []Candidate
+	add := func(p Preset, bonus float64) {
+		c, ok := decodeCandidate(id, p, now)
+		if !ok {
+			return
+		}
+		c.Score += bonus
+		candidates = append(candidates, c)
+	}
+
+	for _, p := range detectPresets {
+		add(p, 1)
+	}
+
+	// 时间只取决于timestamp以下的总位数, 每个总位数给出一个常见的node/sequence划分
+	for _, epoch := range detectEpochs {
+		for total := uint8(10); total <= 22; total++ {
+			sequenceBits := total / 2
+			if total > 12 {
+				sequenceBits = 12
+			}
+			add(Preset{Name: "custom", Epoch: epoch, NodeBits: total - sequenceBits, SequenceBits: sequenceBits}, 0)
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Score > candidates[j].Score
+	})
+	return candidates
+}
+
+func decodeCandidate(id uint64, p Preset, now time.Time) (Candidate, bool) {
+	a := &Algorithm{}
+	if err := WithPreset(p)(a); err != nil || a.setupUnit() != nil {
+		return Candidate{}, false
+	}
+	a.workerBits = a.nodeBits
+	a.setupLayout()
+
+	if id>>a.timestampMoveLength > a.maxTimestamp {
+		return Candidate{}, false
+	}
+
+	parsed := a.decompose(id)
+	t := parsed.GetTime()
+	if t.Before(detectWindowStart) || t.After(now.Add(plausibleClockSkew)) {
+		return Candidate{}, false
+	}
+
+	// 越接近当前时间越可能
+	score := 1 - float64(now.Sub(t))/float64(now.Sub(detectWindowStart))
+	if score < 0 {
+		score = 0
+	}
+
+	return Candidate{
+		Name:         p.Name,
+		Epoch:        p.Epoch,
+		NodeBits:     p.NodeBits,
+		SequenceBits: p.SequenceBits,
+		Time:         t,
+		Node:         parsed.Node,
+		Sequence:     parsed.Sequence,
+		Score:        score,
+	}, true
+}
@@ -0,0 +1,144 @@
+package snowflake
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const (
+	// base58Alphabet is in ascending ASCII order, so the fixed width base58 form sorts like the id.
+	base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+	base62Alphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+	// base58Width is the width of the base58 form, 58^11 > 2^64
+	base58Width = 11
+)
+
+// TextEncoding define the canonical text form of ID used by MarshalText and UnmarshalText,
+// e.g. in YAML, TOML and flag.TextVar.
+type TextEncoding int
+
+const (
+	TextDecimal TextEncoding = iota // the decimal form of ID.String
+	TextBase58                      // the fixed width base58 form of ID.Base58
+)
+
+// DefaultTextEncoding is the encoding used by ID.MarshalText and ID.UnmarshalText, it should be
+// set before any ID is marshaled, recommended you set it in the main function.
+var DefaultTextEncoding = TextDecimal
+
+// MarshalText implements encoding.TextMarshaler, the id is encoded according to DefaultTextEncoding.
+func (i ID) MarshalText() ([]byte, error) {
+	if DefaultTextEncoding == TextBase58 {
+		return []byte(i.Base58()), nil
+	}
+	return MarshalIDText(i.id)
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, the text is decoded according to
+// DefaultTextEncoding only. With TextBase58 the decimal form is not accepted: the base58
+// alphabet has no '0', and a decimal string without '0' decodes to a different id.
+func (i *ID) UnmarshalText(text []byte) error {
+	var id uint64
+	var err error
+	if DefaultTextEncoding == TextBase58 {
+		id, err = ParseBase58(string(text))
+	} else {
+		id, err = UnmarshalIDText(text)
+	}
+	if err != nil {
+		return err
+	}
+
+	*i = ID{id: id}
+	return nil
+}
+
+// String returns the decimal form of the id.
+func (i ID) String() string {
+	return strconv.FormatUint(i.id, 10)
+}
+
+// Base58 returns the 11 characters base58 form of the id, it is zero padded, so the base58
+// forms sort lexicographically by generation time like the ids.
+func (i ID) Base58() string {
+	return formatBase(i.id, base58Width, base58Alphabet)
+}
+
+// Base62 returns the short base62 form of the id, it is not padded.
+func (i ID) Base62() string {
+	return formatBase(i.id, 0, base62Alphabet)
+}
+
+// Hex returns the 16 characters lowercase hexadecimal form of the id, it is zero padded.
+func (i ID) Hex() string {
+	return fmt.Sprintf("%016x", i.id)
+}
+
+// ParseString parse the decimal form produced by ID.String.
+func ParseString(s string) (uint64, error) {
+	return UnmarshalIDText([]byte(s))
+}
+
+// ParseBase58 parse the base58 form produced by ID.Base58.
+func ParseBase58(s string) (uint64, error) {
+	return parseBase(s, base58Alphabet)
+}
+
+// ParseBase62 parse the base62 form produced by ID.Base62.
+func ParseBase62(s string) (uint64, error) {
+	return parseBase(s, base62Alphabet)
+}
+
+// ParseHex parse the hexadecimal form produced by ID.Hex.
+func ParseHex(s string) (uint64, error) {
+	id, err := strconv.ParseUint(s, 16, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid hex snowflake id %q: %w", s, err)
+	}
+	return id, nil
+}
+
+// formatBase returns id in the base of the alphabet, left padded with the zero digit
+// alphabet[0] to width.
+func formatBase(id uint64, width int, alphabet string) string {
+	base := uint64(len(alphabet))
+
+	// 64位在最小的base32下最多13个字符
+	var buf [13]byte
+	i := len(buf) - 1
+	for id >= base {
+		buf[i] = alphabet[id%base]
+		id /= base
+		i--
+	}
+	buf[i] = alphabet[id]
+
+	for len(buf)-i < width {
+		i--
+		buf[i] = alphabet[0]
+	}
+	return string(buf[i:])
+}
+
+func parseBase(s string, alphabet string) (uint64, error) {
+	if s == "" {
+		return 0, fmt.Errorf("invalid base%d snowflake id: empty string", len(alphabet))
+	}
+
+	base := uint64(len(alphabet))
+	var id uint64
+	for i := 0; i < len(s); i++ {
+		d := strings.IndexByte(alphabet, s[i])
+		if d < 0 {
+			return 0, fmt.Errorf("invalid base%d snowflake id: %q", len(alphabet), s)
+		}
+
+		if id > (math.MaxUint64-uint64(d))/base {
+			return 0, fmt.Errorf("base%d snowflake id overflows: %q", len(alphabet), s)
+		}
+		id = id*base + uint64(d)
+	}
+	return id, nil
+}
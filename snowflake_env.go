@@ -0,0 +1,60 @@
+package snowflake
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// the environment variables read by FromEnv
+const (
+	EnvNodeID       = "SNOWFLAKE_NODE_ID"
+	EnvEpochMillis  = "SNOWFLAKE_EPOCH_MS"
+	EnvNodeBits     = "SNOWFLAKE_NODE_BITS"
+	EnvSequenceBits = "SNOWFLAKE_SEQ_BITS"
+)
+
+// FromEnv create the algorithm configured by the environment variables, e.g. in 12-factor
+// deployments: SNOWFLAKE_NODE_ID, SNOWFLAKE_EPOCH_MS, SNOWFLAKE_NODE_BITS and SNOWFLAKE_SEQ_BITS.
+// The unset variables keep the defaults, the options are applied after them.
+func FromEnv(options ...Option) (*Algorithm, error) {
+	var cfg Config
+	var err error
+	if cfg.NodeID, err = lookupEnv(EnvNodeID, 64); err != nil {
+		return nil, err
+	}
+
+	if epoch, exists := os.LookupEnv(EnvEpochMillis); exists {
+		if cfg.Epoch, err = strconv.ParseInt(epoch, 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", EnvEpochMillis, err)
+		}
+	}
+
+	nodeBits, err := lookupEnv(EnvNodeBits, 8)
+	if err != nil {
+		return nil, err
+	}
+	cfg.NodeBits = uint8(nodeBits)
+
+	sequenceBits, err := lookupEnv(EnvSequenceBits, 8)
+	if err != nil {
+		return nil, err
+	}
+	cfg.SequenceBits = uint8(sequenceBits)
+
+	return NewFromConfig(cfg, options...)
+}
+
+// lookupEnv parse the unsigned integer environment variable, it is 0 if not set.
+func lookupEnv(key string, bitSize int) (uint64, error) {
+	v, exists := os.LookupEnv(key)
+	if !exists {
+		return 0, nil
+	}
+
+	n, err := strconv.ParseUint(v, 10, bitSize)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return n, nil
+}
@@ -0,0 +1,87 @@
+package snowflake
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// EventType is the type of the generation event.
+type EventType int
+
+const (
+	EventIssued         EventType = iota + 1 // ids are issued
+	EventWait                                // waited for the next millisecond as the sequences were used up
+	EventClockBackwards                      // the clock was detected going backwards
+)
+
+// subscriberBuffer is the channel buffer size of each subscriber.
+const subscriberBuffer = 256
+
+// Event is a structured generation event for audit pipelines and debugging dashboards.
+type Event struct {
+	Type  EventType
+	Time  time.Time
+	IDs   []uint64      // the issued ids of EventIssued
+	Wait  time.Duration // the wait time of EventWait
+	Drift time.Duration // how far the clock went backwards of EventClockBackwards
+}
+
+// Subscribe returns a channel receiving the generation events of the algorithm.
+// Events are dropped if the receiver can't keep up, so generation is never blocked.
+func (a *Algorithm) Subscribe() <-chan Event {
+	return a.events.subscribe()
+}
+
+// Unsubscribe stop delivering events to ch and close it.
+func (a *Algorithm) Unsubscribe(ch <-chan Event) {
+	a.events.unsubscribe(ch)
+}
+
+type broker struct {
+	mu          sync.RWMutex
+	subscribers []chan Event
+	count       atomic.Int32
+}
+
+func (b *broker) active() bool {
+	return b.count.Load() > 0
+}
+
+func (b *broker) subscribe() <-chan Event {
+	ch := make(chan Event, subscriberBuffer)
+
+	b.mu.Lock()
+	b.subscribers = append(b.subscribers, ch)
+	b.count.Store(int32(len(b.subscribers)))
+	b.mu.Unlock()
+	return ch
+}
+
+func (b *broker) unsubscribe(ch <-chan Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for i, sub := range b.subscribers {
+		if sub == ch {
+			b.subscribers = append(b.subscribers[:i], b.subscribers[i+1:]...)
+			b.count.Store(int32(len(b.subscribers)))
+			close(sub)
+			return
+		}
+	}
+}
+
+func (b *broker) publish(e Event) {
+	e.Time = time.Now()
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, sub := range b.subscribers {
+		select {
+		case sub <- e:
+		default:
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package snowflake
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"hash"
+)
+
+// feistelRounds is the rounds of the feistel network, 4 rounds already make a strong pseudorandom
+// permutation, 6 rounds leave a safety margin.
+const feistelRounds = 6
+
+// feistel is a keyed permutation on [0, 2^bits) built from a balanced feistel network
+// with HMAC-SHA256 as the round function, cycle walking keeps odd widths in range.
+type feistel struct {
+	mac      hash.Hash
+	bits     uint8
+	halfBits uint8
+}
+
+func newFeistel(key []byte, bits uint8) *feistel {
+	return &feistel{
+		mac:      hmac.New(sha256.New, key),
+		bits:     bits,
+		halfBits: (bits + 1) / 2,
+	}
+}
+
+// encrypt map v to its permutation, v must be less than 2^bits.
+// It is not thread safe.
+func (f *feistel) encrypt(v uint64) uint64 {
+	for {
+		v = f.permute(v)
+		if f.bits == 64 || v < 1<<f.bits {
+			return v
+		}
+	}
+}
+
+// decrypt map the permutation v back to its origin, v must be less than 2^bits.
+// It is not thread safe.
+func (f *feistel) decrypt(v uint64) uint64 {
+	for {
+		v = f.unpermute(v)
+		if f.bits == 64 || v < 1<<f.bits {
+			return v
+		}
+	}
+}
+
+func (f *feistel) permute(v uint64) uint64 {
+	mask := uint64(1)<<f.halfBits - 1
+	left, right := v>>f.halfBits&mask, v&mask
+
+	for i := 0; i < feistelRounds; i++ {
+		left, right = right, left^f.round(i, right)&mask
+	}
+	return left<<f.halfBits | right
+}
+
+func (f *feistel) unpermute(v uint64) uint64 {
+	mask := uint64(1)<<f.halfBits - 1
+	left, right := v>>f.halfBits&mask, v&mask
+
+	for i := feistelRounds - 1; i >= 0; i-- {
+		left, right = right^f.round(i, left)&mask, left
+	}
+	return left<<f.halfBits | right
+}
+
+func (f *feistel) round(round int, v uint64) uint64 {
+	var buf [9]byte
+	buf[0] = byte(round)
+	binary.BigEndian.PutUint64(buf[1:], v)
+
+	f.mac.Reset()
+	f.mac.Write(buf[:])
+	return binary.BigEndian.Uint64(f.mac.Sum(nil))
+}
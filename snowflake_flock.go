@@ -0,0 +1,20 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+
+package snowflake
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+var errFileLocked = errors.New("the file is locked by another process")
+
+// tryLockFile take the exclusive lock of f without blocking, it is released when f is closed.
+func tryLockFile(f *os.File) error {
+	err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	if errors.Is(err, syscall.EWOULDBLOCK) {
+		return errFileLocked
+	}
+	return err
+}
@@ -0,0 +1,15 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly)
+
+package snowflake
+
+import (
+	"errors"
+	"os"
+)
+
+var errFileLocked = errors.New("the file is locked by another process")
+
+// tryLockFile is a no-op on this platform, the file locks are not supported.
+func tryLockFile(f *os.File) error {
+	return nil
+}
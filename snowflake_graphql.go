@@ -0,0 +1,38 @@
+package snowflake
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// MarshalGQL implements the gqlgen graphql.Marshaler interface, the id is
+// serialized as a string to avoid the Int overflow in GraphQL clients.
+// Declare it as a custom scalar in gqlgen.yml:
+//
+//	models:
+//	  SnowflakeID:
+//	    model: github.com/hdget/snowflake.ID
+func (i ID) MarshalGQL(w io.Writer) {
+	_, _ = io.WriteString(w, strconv.Quote(strconv.FormatUint(i.id, 10)))
+}
+
+// UnmarshalGQL implements the gqlgen graphql.Unmarshaler interface,
+// it accepts the id as a string or an integer.
+func (i *ID) UnmarshalGQL(v any) error {
+	var id uint64
+	var err error
+	switch n := v.(type) {
+	case json.Number:
+		id, err = strconv.ParseUint(n.String(), 10, 64)
+	default:
+		id, err = toUint64(v)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid snowflake id: %v", v)
+	}
+
+	*i = ID{id: id}
+	return nil
+}
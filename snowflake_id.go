@@ -5,14 +5,36 @@ import (
 )
 
 // ID snowflake id
+//
+// An ID restored by the unmarshal methods only carries the raw id,
+// use Algorithm.Parse(id.Uint64()) to decompose it.
 type ID struct {
-	startTime time.Time
-	Sequence  uint64
-	Node      uint64
-	Timestamp uint64
+	id          uint64
+	startTime   time.Time
+	precision   time.Duration // the tick length, millisecond if zero
+	unit        int64         // ticks per timestamp unit
+	Sequence    uint64
+	Node        uint64
+	Datacenter  uint64 // the datacenter set by WithDatacenter, the high bits of Node
+	Worker      uint64 // the worker in the datacenter, the low bits of Node
+	Environment uint64 // the environment code set by WithEnvironment
+	Era         uint64 // the era set by WithEras
+	Timestamp   uint64 // the elapsed time units (milliseconds by default) since the epoch of the era
 }
 
 func (i ID) GetTime() time.Time {
-	ms := i.startTime.UTC().UnixNano()/1e6 + int64(i.Timestamp)
-	return time.Unix(0, ms*int64(time.Millisecond)).UTC()
+	unit, precision := i.unit, i.precision
+	if unit == 0 {
+		unit = 1
+	}
+	if precision == 0 {
+		precision = time.Millisecond
+	}
+	ticks := i.startTime.UnixNano()/int64(precision) + int64(i.Timestamp)*unit
+	return tickTime(ticks, precision)
+}
+
+// Uint64 returns the raw snowflake id.
+func (i ID) Uint64() uint64 {
+	return i.id
 }
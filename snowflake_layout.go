@@ -0,0 +1,120 @@
+package snowflake
+
+import (
+	"errors"
+	"time"
+)
+
+// Layout is the split of the id bits between the timestamp, the node and the sequence.
+type Layout struct {
+	TimestampBits uint8
+	NodeBits      uint8
+	SequenceBits  uint8
+	// Precision is the resolution of the timestamp, time.Millisecond if zero or time.Microsecond
+	Precision time.Duration
+}
+
+// LayoutTwitter is the classic twitter layout: 41 bit timestamp, 10 bit node and 12 bit sequence,
+// compatible with the snowflake ids of the Java/Scala services.
+// The ids use up to 63 bits, they are not safe for JavaScript numbers.
+var LayoutTwitter = Layout{TimestampBits: 41, NodeBits: 10, SequenceBits: 12}
+
+// LayoutMicrosecond is the microsecond precision layout for bursty workloads: 52 bit timestamp
+// in microseconds, 6 bit node and 5 bit sequence. It has 1000 times more ticks than a millisecond
+// layout, 32 ids per microsecond per node, for a life cycle of about 142 years.
+// The ids use up to 63 bits, they are not safe for JavaScript numbers.
+var LayoutMicrosecond = Layout{TimestampBits: 52, NodeBits: 6, SequenceBits: 5, Precision: time.Microsecond}
+
+// Validate check whether the layout fits in a 63-bit id.
+func (l Layout) Validate() error {
+	if l.TimestampBits == 0 || l.NodeBits == 0 || l.SequenceBits == 0 {
+		return errors.New("the timestamp bits, node bits and sequence bits of the layout must be positive")
+	}
+
+	// node和sequence至少各1位
+	if l.TimestampBits > 61 {
+		return errors.New("the timestamp bits of the layout cannot be greater than 61")
+	}
+
+	// maxNode和maxSequence为uint32
+	if l.NodeBits > 31 || l.SequenceBits > 31 {
+		return errors.New("the node bits and sequence bits of the layout cannot be greater than 31")
+	}
+
+	// 以int求和, uint8会溢出回绕
+	if l.bits() > 63 {
+		return errors.New("the layout cannot be greater than 63 bits")
+	}
+
+	if l.Precision != 0 && l.Precision != time.Millisecond && l.Precision != time.Microsecond {
+		return errors.New("the precision of the layout must be millisecond or microsecond")
+	}
+	return nil
+}
+
+// Decompose split id into fields by layout and epoch without an algorithm instance, e.g. to
+// decode the ids from foreign services in ops tooling. The layout should be valid, see Validate.
+func Decompose(id uint64, layout Layout, epoch time.Time) ID {
+	a := &Algorithm{
+		startTime:     epoch.UTC(),
+		precision:     layout.precision(),
+		unit:          1,
+		timestampBits: layout.TimestampBits,
+		nodeBits:      layout.NodeBits,
+		workerBits:    layout.NodeBits,
+		sequenceBits:  layout.SequenceBits,
+	}
+	a.setupLayout()
+	return a.decompose(id)
+}
+
+// bits returns the total bits of the layout.
+func (l Layout) bits() int {
+	return int(l.TimestampBits) + int(l.NodeBits) + int(l.SequenceBits)
+}
+
+func (l Layout) precision() time.Duration {
+	if l.Precision == 0 {
+		return time.Millisecond
+	}
+	return l.Precision
+}
+
+// WithLayout set the layout of the algorithm to one of the predefined layouts, e.g. LayoutTwitter.
+// It is the same as WithCustomLayout.
+func WithLayout(l Layout) Option {
+	return WithCustomLayout(l)
+}
+
+// WithCustomLayout set the timestamp bits, node bits and sequence bits of the algorithm to l,
+// e.g. 39/16/8 for many nodes with low throughput. A shorter timestamp shortens the life cycle
+// of the algorithm, combine it with WithTimeUnit to compensate.
+// The 63-bit mode of WithWideIDs is enabled if l does not fit in 53 bits.
+// With the microsecond precision the time unit and granularity options still take multiples
+// of millisecond, and the persisted states, e.g. WithStateFile, are kept in microseconds.
+func WithCustomLayout(l Layout) Option {
+	return func(a *Algorithm) error {
+		if err := l.Validate(); err != nil {
+			return err
+		}
+
+		a.timestampBits = l.TimestampBits
+		a.nodeBits = l.NodeBits
+		a.sequenceBits = l.SequenceBits
+		a.precision = l.precision()
+		if l.bits() > 53 {
+			a.wide = true
+		}
+		return nil
+	}
+}
+
+// WithWideIDs enable the 63-bit mode: the era, environment, node and sequence bits share
+// 22 bits instead of 12 with the default timestamp bits, the ids are not safe for JavaScript
+// numbers any more. Combine it with WithNodeBits and WithSequenceBits for a custom wide layout.
+func WithWideIDs() Option {
+	return func(a *Algorithm) error {
+		a.wide = true
+		return nil
+	}
+}
@@ -0,0 +1,47 @@
+package snowflake
+
+import (
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+// lifetimeWarning calls fn once when the remaining lifetime of the timestamp space is
+// within threshold.
+type lifetimeWarning struct {
+	threshold time.Duration
+	fn        func(remaining time.Duration)
+	fired     atomic.Bool
+}
+
+// WithLifetimeWarning call fn once the ids are generated within threshold of the end of the
+// timestamp space, including the eras, so operators are warned years before NextID starts
+// returning ErrLifetimeExceeded. fn is called once per algorithm instance in a new goroutine,
+// so generation is never blocked.
+func WithLifetimeWarning(threshold time.Duration, fn func(remaining time.Duration)) Option {
+	return func(a *Algorithm) error {
+		if threshold <= 0 {
+			return errors.New("the lifetime warning threshold must be positive")
+		}
+		if fn == nil {
+			return errors.New("invalid lifetime warning callback")
+		}
+
+		a.lifetimeWarning = &lifetimeWarning{threshold: threshold, fn: fn}
+		return nil
+	}
+}
+
+// check fire the warning if the elapsed timestamp df is within the threshold of the end.
+func (w *lifetimeWarning) check(a *Algorithm, df uint64) {
+	if w.fired.Load() {
+		return
+	}
+
+	// 先以tick比较, 避免剩余时间超出time.Duration的范围
+	remaining := int64(a.maxElapsed-df) * a.unit
+	if remaining > int64(w.threshold/a.precision) || !w.fired.CompareAndSwap(false, true) {
+		return
+	}
+	go w.fn(time.Duration(remaining) * a.precision)
+}
@@ -0,0 +1,74 @@
+package snowflake
+
+import (
+	"errors"
+	"math"
+	"sync/atomic"
+	"time"
+)
+
+// ErrMachineIDRejected is returned when the machine id checker reports the node id is no longer
+// uniquely held, the algorithm stops generating ids from then on.
+var ErrMachineIDRejected = errors.New("the machine id is rejected by the checker")
+
+// machineIDChecker verifies the node id at startup and every interval.
+type machineIDChecker struct {
+	check    func(uint16) bool
+	interval time.Duration // 为0时只在启动时检查
+	ticks    int64         // interval in ticks
+	next     atomic.Int64
+	rejected atomic.Bool
+}
+
+// WithMachineIDChecker verify the node id is uniquely held by calling check, e.g. by looking
+// it up in a registry, like the CheckMachineID of sonyflake. New fails if check returns false.
+// If interval is positive, NextID calls check again once the interval passed, and returns
+// ErrMachineIDRejected from the first failed check on. The node id must fit in 16 bits.
+func WithMachineIDChecker(check func(uint16) bool, interval time.Duration) Option {
+	return func(a *Algorithm) error {
+		if check == nil {
+			return errors.New("invalid machine id checker")
+		}
+		if interval < 0 {
+			return errors.New("the machine id check interval cannot be negative")
+		}
+
+		a.machineChecker = &machineIDChecker{check: check, interval: interval}
+		return nil
+	}
+}
+
+// setup run the startup check of the node id.
+func (c *machineIDChecker) setup(a *Algorithm) error {
+	if a.nodeId > math.MaxUint16 {
+		return errors.New("the machine id checker requires a node id within 16 bits")
+	}
+
+	if !c.check(uint16(a.nodeId)) {
+		return ErrMachineIDRejected
+	}
+	c.ticks = int64(c.interval / a.precision)
+	c.next.Store(a.millis() + c.ticks)
+	return nil
+}
+
+// verify check the node id again if the interval passed, only one caller runs the check.
+func (c *machineIDChecker) verify(a *Algorithm) error {
+	if c.rejected.Load() {
+		return ErrMachineIDRejected
+	}
+	if c.ticks == 0 {
+		return nil
+	}
+
+	now, next := a.millis(), c.next.Load()
+	if now < next || !c.next.CompareAndSwap(next, now+c.ticks) {
+		return nil
+	}
+
+	if !c.check(uint16(a.nodeId)) {
+		c.rejected.Store(true)
+		return ErrMachineIDRejected
+	}
+	return nil
+}
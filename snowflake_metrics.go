@@ -0,0 +1,33 @@
+package snowflake
+
+import (
+	"errors"
+	"time"
+)
+
+// Metrics receives the generation metrics of the algorithm, e.g. to alert on generator
+// saturation. The methods are called synchronously on the hot path, they must be cheap
+// and thread safe. metrics/prometheus provides a Prometheus implementation.
+type Metrics interface {
+	// Issued is called for every issued id with the generation latency.
+	Issued(latency time.Duration)
+	// Waited is called when NextID waited for the next millisecond as the sequences were used up.
+	Waited(wait time.Duration)
+	// ClockBackwards is called when the clock was detected going backwards by drift.
+	ClockBackwards(drift time.Duration)
+	// ClockBackwardsWaited is called when NextID waited for the clock to catch up with the last
+	// issued tick after the clock went backwards, it is not counted by Waited.
+	ClockBackwardsWaited(wait time.Duration)
+}
+
+// WithMetrics report the generation metrics of the algorithm to m.
+func WithMetrics(m Metrics) Option {
+	return func(a *Algorithm) error {
+		if m == nil {
+			return errors.New("invalid metrics")
+		}
+
+		a.metrics = m
+		return nil
+	}
+}
@@ -0,0 +1,67 @@
+package snowflake
+
+import (
+	"fmt"
+	"time"
+)
+
+// MigrationReport is the result of Migrate.
+type MigrationReport struct {
+	Mapping map[uint64]uint64 // old id -> new id
+	Failed  map[uint64]error  // old ids which cannot be represented in the new layout
+}
+
+// Reencode re-encode id generated by from into the layout and epoch of to.
+// The generation time, environment, node and sequence of id are kept, so the mapping
+// is deterministic and collision-free. An error is returned if any of them can not be
+// represented by to, the fields are never truncated.
+func Reencode(from, to *Algorithm, id uint64) (uint64, error) {
+	raw := from.deobfuscate(id)
+	parsed := from.decompose(raw)
+
+	// 包含era的完整时间戳, 以微秒换算不同的精度
+	us := (from.epochTicks() + int64(raw>>from.timestampMoveLength)*from.unit) * int64(from.precision/time.Microsecond)
+	scale := int64(to.precision / time.Microsecond)
+	ticks := us / scale
+	df := to.elapsed(ticks)
+	if df < 0 || uint64(df) > to.maxElapsed {
+		return 0, fmt.Errorf("the time of id %d is out of the life cycle of the new epoch", id)
+	}
+
+	if us%scale != 0 || (ticks-to.epochTicks())%to.unit != 0 {
+		return 0, fmt.Errorf("the time of id %d is not a multiple of the new time unit", id)
+	}
+
+	if parsed.Environment >= 1<<to.envBits {
+		return 0, fmt.Errorf("the environment %d of id %d is out of the new environment bits", parsed.Environment, id)
+	}
+
+	if parsed.Node > uint64(to.maxNode) {
+		return 0, fmt.Errorf("the node %d of id %d is out of the new node bits", parsed.Node, id)
+	}
+
+	if parsed.Sequence > uint64(to.maxSequence) {
+		return 0, fmt.Errorf("the sequence %d of id %d is out of the new sequence bits", parsed.Sequence, id)
+	}
+
+	return to.obfuscate(uint64(df)<<to.timestampMoveLength | parsed.Environment<<to.envMoveLength |
+		parsed.Node<<to.nodeMoveLength | parsed.Sequence<<to.sequenceMoveLength), nil
+}
+
+// Migrate re-encode ids generated by from into the layout and epoch of to, see Reencode.
+func Migrate(from, to *Algorithm, ids []uint64) MigrationReport {
+	report := MigrationReport{
+		Mapping: make(map[uint64]uint64, len(ids)),
+		Failed:  make(map[uint64]error),
+	}
+
+	for _, id := range ids {
+		newId, err := Reencode(from, to, id)
+		if err != nil {
+			report.Failed[id] = err
+			continue
+		}
+		report.Mapping[id] = newId
+	}
+	return report
+}
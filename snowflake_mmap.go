@@ -0,0 +1,80 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+
+package snowflake
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	// mmapStateSize is the size of the state file, it stores the last issued millisecond as int64.
+	mmapStateSize = 8
+	// sharedStateSize is the size of the shared state file, it stores the sequence state:
+	// the last tick and its last sequence packed into one uint64, updated by a single CAS.
+	sharedStateSize = 8
+)
+
+// WithMmapState record the millisecond of the last issued id in a memory-mapped file.
+// The record is a plain memory write per id without fsync, the kernel flushes it to the file,
+// so it survives process crashes. On start the algorithm never issues ids at or before the
+// recorded millisecond, NextID waits if the clock is behind it, e.g. after a restart onto
+// a skewed clock. The file is mapped for the lifetime of the process.
+func WithMmapState(path string) Option {
+	return func(a *Algorithm) error {
+		data, err := mmapFile(path, mmapStateSize)
+		if err != nil {
+			return err
+		}
+
+		// mmap返回的地址按页对齐, 可以安全地进行原子操作
+		a.mmapState = (*int64)(unsafe.Pointer(&data[0]))
+		return nil
+	}
+}
+
+// WithSharedState keep the sequence state in a memory-mapped file, so multiple processes on
+// one host mapping the same file share the state with atomic operations, and can safely
+// share a single node id at full speed. The last tick and sequence are packed into one word
+// updated by a single compare-and-swap, so a (tick, sequence) pair is never issued twice.
+// All the processes must use the same options, and it cannot be combined with WithSequenceResolver.
+// The file is mapped for the lifetime of the process.
+func WithSharedState(path string) Option {
+	return func(a *Algorithm) error {
+		data, err := mmapFile(path, sharedStateSize)
+		if err != nil {
+			return err
+		}
+
+		// 序列状态在New确定layout之后创建
+		a.sharedState = (*uint64)(unsafe.Pointer(&data[0]))
+		return nil
+	}
+}
+
+// mmapFile map the first size bytes of the file at path, the file is created or extended as needed.
+func mmapFile(path string, size int) ([]byte, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() < int64(size) {
+		if err = f.Truncate(int64(size)); err != nil {
+			return nil, err
+		}
+	}
+
+	data, err := syscall.Mmap(int(f.Fd()), 0, size, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, fmt.Errorf("mmap %s: %w", path, err)
+	}
+	return data, nil
+}
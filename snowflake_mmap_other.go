@@ -0,0 +1,23 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly)
+
+package snowflake
+
+import (
+	"errors"
+)
+
+// WithMmapState record the millisecond of the last issued id in a memory-mapped file.
+// It is not supported on this platform.
+func WithMmapState(path string) Option {
+	return func(a *Algorithm) error {
+		return errors.New("mmap state is not supported on this platform")
+	}
+}
+
+// WithSharedState keep the sequence state in a memory-mapped file shared by multiple processes.
+// It is not supported on this platform.
+func WithSharedState(path string) Option {
+	return func(a *Algorithm) error {
+		return errors.New("shared state is not supported on this platform")
+	}
+}
@@ -0,0 +1,59 @@
+package snowflake
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// MarshalMsgpack implements the msgpack.Marshaler interface of github.com/vmihailenco/msgpack,
+// the id is always encoded as a msgpack uint64, so it never round-trips through float64.
+func (i ID) MarshalMsgpack() ([]byte, error) {
+	b := make([]byte, 9)
+	b[0] = 0xcf
+	binary.BigEndian.PutUint64(b[1:], i.id)
+	return b, nil
+}
+
+// UnmarshalMsgpack implements the msgpack.Unmarshaler interface of github.com/vmihailenco/msgpack,
+// it accepts any non-negative msgpack integer.
+func (i *ID) UnmarshalMsgpack(data []byte) error {
+	if len(data) == 0 {
+		return errors.New("invalid msgpack snowflake id: empty data")
+	}
+
+	var id uint64
+	var signed int64
+	switch code, body := data[0], data[1:]; {
+	case code <= 0x7f && len(body) == 0: // positive fixint
+		id = uint64(code)
+	case code == 0xcc && len(body) == 1:
+		id = uint64(body[0])
+	case code == 0xcd && len(body) == 2:
+		id = uint64(binary.BigEndian.Uint16(body))
+	case code == 0xce && len(body) == 4:
+		id = uint64(binary.BigEndian.Uint32(body))
+	case code == 0xcf && len(body) == 8:
+		id = binary.BigEndian.Uint64(body)
+	case code == 0xd0 && len(body) == 1:
+		signed = int64(int8(body[0]))
+	case code == 0xd1 && len(body) == 2:
+		signed = int64(int16(binary.BigEndian.Uint16(body)))
+	case code == 0xd2 && len(body) == 4:
+		signed = int64(int32(binary.BigEndian.Uint32(body)))
+	case code == 0xd3 && len(body) == 8:
+		signed = int64(binary.BigEndian.Uint64(body))
+	default:
+		return fmt.Errorf("invalid msgpack snowflake id: unexpected code 0x%x", code)
+	}
+
+	if signed < 0 {
+		return fmt.Errorf("invalid msgpack snowflake id: %d", signed)
+	}
+	if signed > 0 {
+		id = uint64(signed)
+	}
+
+	*i = ID{id: id}
+	return nil
+}
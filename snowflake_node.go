@@ -0,0 +1,160 @@
+package snowflake
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// NodeIDProvider provides the node id to New, e.g. by acquiring a lease from a registry.
+// NodeID is called with the maximum node id of the configured layout.
+// All the node id assignment strategies are providers: the machine based ones in this package,
+// the local lock files of nodeid/filelock, and the registry based ones in nodeid/etcd,
+// nodeid/redis and nodeid/zookeeper.
+type NodeIDProvider interface {
+	NodeID(maxNode uint32) (uint64, error)
+}
+
+// NodeIDProviderFunc adapts a function to NodeIDProvider.
+type NodeIDProviderFunc func(maxNode uint32) (uint64, error)
+
+func (f NodeIDProviderFunc) NodeID(maxNode uint32) (uint64, error) {
+	return f(maxNode)
+}
+
+var (
+	// AutoNodeID hash the machine's private IPv4 address, or the MAC address if there is none,
+	// into [1, maxNode]. Different machines may still hash to the same node id, the bigger the
+	// node bits the less likely.
+	AutoNodeID NodeIDProvider = NodeIDProviderFunc(func(maxNode uint32) (uint64, error) {
+		identity, err := privateIPv4()
+		if err != nil {
+			identity, err = macAddress()
+		}
+		if err != nil {
+			return 0, errors.New("no private IPv4 or MAC address found for the node id")
+		}
+		return hashNodeID(identity, maxNode), nil
+	})
+
+	// IPNodeID hash the machine's first private IPv4 address into [1, maxNode].
+	IPNodeID NodeIDProvider = NodeIDProviderFunc(func(maxNode uint32) (uint64, error) {
+		ip, err := privateIPv4()
+		if err != nil {
+			return 0, err
+		}
+		return hashNodeID(ip, maxNode), nil
+	})
+
+	// MACNodeID hash the machine's first MAC address into [1, maxNode].
+	MACNodeID NodeIDProvider = NodeIDProviderFunc(func(maxNode uint32) (uint64, error) {
+		mac, err := macAddress()
+		if err != nil {
+			return 0, err
+		}
+		return hashNodeID(mac, maxNode), nil
+	})
+
+	// StatefulSetNodeID use the ordinal of the kubernetes StatefulSet pod plus one as the node id,
+	// the ordinal is the suffix of the hostname, e.g. 3 of web-3. The node ids are collision-free
+	// as long as the replicas fit in [1, maxNode].
+	StatefulSetNodeID NodeIDProvider = NodeIDProviderFunc(func(maxNode uint32) (uint64, error) {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return 0, err
+		}
+
+		i := strings.LastIndexByte(hostname, '-')
+		ordinal, err := strconv.ParseUint(hostname[i+1:], 10, 32)
+		if i < 0 || err != nil {
+			return 0, fmt.Errorf("hostname %s is not a StatefulSet pod name", hostname)
+		}
+		if ordinal >= uint64(maxNode) {
+			return 0, fmt.Errorf("the StatefulSet ordinal %d is out of the node bits", ordinal)
+		}
+		return ordinal + 1, nil
+	})
+)
+
+// WithNodeIDProvider let New get the node id from p, the node id passed to New is ignored.
+func WithNodeIDProvider(p NodeIDProvider) Option {
+	return func(a *Algorithm) error {
+		if p == nil {
+			return errors.New("invalid node id provider")
+		}
+		a.nodeIdProvider = p
+		return nil
+	}
+}
+
+// WithAutoNodeID derive the node id from the machine's private IPv4 address, or the MAC
+// address if there is none, hashed into the configured node bits range. The node id
+// passed to New is ignored. It is the same as WithNodeIDProvider(AutoNodeID).
+func WithAutoNodeID() Option {
+	return WithNodeIDProvider(AutoNodeID)
+}
+
+// hashNodeID hash the machine's network identity into [1, maxNode].
+func hashNodeID(identity []byte, maxNode uint32) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write(identity)
+	return h.Sum64()%uint64(maxNode) + 1
+}
+
+// upInterfaces returns the up, non-loopback network interfaces.
+func upInterfaces() ([]net.Interface, error) {
+	interfaces, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+
+	up := interfaces[:0]
+	for _, iface := range interfaces {
+		if iface.Flags&net.FlagUp != 0 && iface.Flags&net.FlagLoopback == 0 {
+			up = append(up, iface)
+		}
+	}
+	return up, nil
+}
+
+// privateIPv4 returns the first private IPv4 address of the up interfaces.
+func privateIPv4() ([]byte, error) {
+	interfaces, err := upInterfaces()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, iface := range interfaces {
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			if ipNet, ok := addr.(*net.IPNet); ok {
+				if ip := ipNet.IP.To4(); ip != nil && ip.IsPrivate() {
+					return ip, nil
+				}
+			}
+		}
+	}
+	return nil, errors.New("no private IPv4 address found for the node id")
+}
+
+// macAddress returns the first MAC address of the up interfaces.
+func macAddress() ([]byte, error) {
+	interfaces, err := upInterfaces()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, iface := range interfaces {
+		if len(iface.HardwareAddr) > 0 {
+			return iface.HardwareAddr, nil
+		}
+	}
+	return nil, errors.New("no MAC address found for the node id")
+}
@@ -0,0 +1,62 @@
+package snowflake
+
+import (
+	"errors"
+	"sync"
+)
+
+// obfuscator is a keyed permutation on the ids of the layout, safe for concurrent use.
+type obfuscator struct {
+	bits uint8
+	pool sync.Pool
+}
+
+// WithObfuscation permute the generated ids with a keyed feistel network, so the public ids
+// don't expose the creation rate and volume. The permutation keeps the bit width of the layout,
+// so the ids are still unique and JavaScript safe, but they are no longer ordered by time.
+// Parse, ParseStrict, Validate and Reencode reverse the permutation before decoding, the key
+// must be kept secret and never change for the ids issued.
+func WithObfuscation(key []byte) Option {
+	return func(a *Algorithm) error {
+		if len(key) == 0 {
+			return errors.New("the obfuscation key cannot be empty")
+		}
+
+		a.obfuscationKey = append([]byte(nil), key...)
+		return nil
+	}
+}
+
+func newObfuscator(key []byte, bits uint8) *obfuscator {
+	o := &obfuscator{bits: bits}
+	o.pool.New = func() any {
+		return newFeistel(key, bits)
+	}
+	return o
+}
+
+// obfuscate returns the public form of the raw id.
+func (a *Algorithm) obfuscate(id uint64) uint64 {
+	if a.obfuscator == nil {
+		return id
+	}
+	return a.obfuscator.apply(id, (*feistel).encrypt)
+}
+
+// deobfuscate returns the raw id of the public id, the ids beyond the layout are kept as is.
+func (a *Algorithm) deobfuscate(id uint64) uint64 {
+	if a.obfuscator == nil {
+		return id
+	}
+	return a.obfuscator.apply(id, (*feistel).decrypt)
+}
+
+func (o *obfuscator) apply(id uint64, fn func(*feistel, uint64) uint64) uint64 {
+	if o.bits < 64 && id >= 1<<o.bits {
+		return id
+	}
+
+	f := o.pool.Get().(*feistel)
+	defer o.pool.Put(f)
+	return fn(f, id)
+}
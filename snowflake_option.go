@@ -2,6 +2,9 @@ package snowflake
 
 import (
 	"errors"
+	"fmt"
+	"regexp"
+	"strings"
 	"time"
 )
 
@@ -38,6 +41,11 @@ func WithStartTime(t time.Time) Option {
 	}
 }
 
+// WithEpochMillis set the start time for snowflake algorithm in unix milliseconds, like WithStartTime.
+func WithEpochMillis(ms int64) Option {
+	return WithStartTime(time.UnixMilli(ms))
+}
+
 func WithNodeBits(nodeBits uint8) Option {
 	return func(a *Algorithm) error {
 		if nodeBits == 0 {
@@ -69,3 +77,244 @@ func WithSequenceBits(sequenceBits uint8) Option {
 		return nil
 	}
 }
+
+// WithStrictMonotonic guarantee every id returned by the algorithm instance is
+// numerically greater than the previous one, even if the clock is adjusted backwards.
+// When the clock goes backwards, ids keep increasing from the highest id issued so far.
+func WithStrictMonotonic() Option {
+	return func(a *Algorithm) error {
+		a.strictMonotonic = true
+		return nil
+	}
+}
+
+// WithPriorityReserve reserve the last n sequences of each millisecond for NextIDPriority,
+// NextID will wait for the next millisecond once the other sequences are used up.
+func WithPriorityReserve(n uint32) Option {
+	return func(a *Algorithm) error {
+		a.priorityReserve = n
+		return nil
+	}
+}
+
+// WithSequenceStart set the first sequence used in each millisecond, the sequences
+// below start are never issued. It helps to avoid overlapping with the ids issued by
+// another generator in the same millisecond window when migrating from it.
+func WithSequenceStart(start uint32) Option {
+	return func(a *Algorithm) error {
+		a.sequenceStart = start
+		return nil
+	}
+}
+
+// WithSkipSuffix skip the ids whose decimal form ends with any of the suffixes,
+// e.g. WithSkipSuffix("4") for the customer-visible order numbers in some markets.
+// The skipped ids are regenerated transparently, the suffixes covering every decimal
+// form, e.g. all ten digits, are rejected.
+func WithSkipSuffix(suffixes ...string) Option {
+	return func(a *Algorithm) error {
+		for _, suffix := range suffixes {
+			if suffix == "" || strings.Trim(suffix, "0123456789") != "" {
+				return fmt.Errorf("invalid decimal suffix: %q", suffix)
+			}
+		}
+		if coversSuffix(suffixes, "") {
+			return errors.New("the decimal suffixes reject every id")
+		}
+
+		a.decimalFilters = append(a.decimalFilters, func(s string) bool {
+			for _, suffix := range suffixes {
+				if strings.HasSuffix(s, suffix) {
+					return true
+				}
+			}
+			return false
+		})
+		return nil
+	}
+}
+
+// WithSkipPattern skip the ids whose decimal form matches any of the patterns.
+// The skipped ids are regenerated transparently, the obviously total patterns like "."
+// or `\d` are rejected. NextID returns ErrSkipExhausted if the filters keep rejecting ids.
+func WithSkipPattern(patterns ...*regexp.Regexp) Option {
+	return func(a *Algorithm) error {
+		for _, p := range patterns {
+			if p == nil {
+				return errors.New("invalid decimal pattern")
+			}
+			if matchesAll(p, skipPatternProbes) {
+				return fmt.Errorf("the decimal pattern %q rejects every id", p)
+			}
+		}
+
+		a.decimalFilters = append(a.decimalFilters, func(s string) bool {
+			for _, p := range patterns {
+				if p.MatchString(s) {
+					return true
+				}
+			}
+			return false
+		})
+		return nil
+	}
+}
+
+// skipPatternProbes are the decimal forms used to detect the patterns rejecting every id.
+var skipPatternProbes = []string{
+	"0", "1", "2", "3", "4", "5", "6", "7", "8", "9",
+	"1234567890", "9007199254740991", "18446744073709551615",
+}
+
+// coversSuffix check whether every decimal form ending with tail ends with one of the suffixes.
+func coversSuffix(suffixes []string, tail string) bool {
+	longer := false
+	for _, suffix := range suffixes {
+		if suffix == tail {
+			return true
+		}
+		if len(suffix) > len(tail) && strings.HasSuffix(suffix, tail) {
+			longer = true
+		}
+	}
+	if !longer {
+		return false
+	}
+
+	// 所有在tail前加一位数字的形式都被覆盖
+	for d := '0'; d <= '9'; d++ {
+		if !coversSuffix(suffixes, string(d)+tail) {
+			return false
+		}
+	}
+	return true
+}
+
+func matchesAll(p *regexp.Regexp, probes []string) bool {
+	for _, s := range probes {
+		if !p.MatchString(s) {
+			return false
+		}
+	}
+	return true
+}
+
+// WithRecorder record the (timestamp, sequence) decision of every id into t.
+func WithRecorder(t *Timeline) Option {
+	return func(a *Algorithm) error {
+		if t == nil {
+			return errors.New("invalid recorder timeline")
+		}
+		a.recorder = t
+		return nil
+	}
+}
+
+// WithReplay make the algorithm take the decisions from t instead of the clock and
+// the sequence state, so the ids of a recorded run are reproduced exactly.
+// The algorithm must be created with the same node and options as the recorded one.
+func WithReplay(t *Timeline) Option {
+	return func(a *Algorithm) error {
+		if t == nil {
+			return errors.New("invalid replay timeline")
+		}
+		a.replay = t
+		return nil
+	}
+}
+
+// WithEnvironment dedicate bits between the timestamp and the node to an environment code,
+// e.g. 0 for prod and 1 for staging, so ids minted in different environments never collide
+// and can be told apart by ID.Environment. One or two bits are usually enough.
+func WithEnvironment(bits uint8, code uint64) Option {
+	return func(a *Algorithm) error {
+		if bits == 0 || bits > 4 {
+			return errors.New("the environment bits must be between 1 and 4")
+		}
+
+		if code >= 1<<bits {
+			return fmt.Errorf("the environment code cannot be greater than %d", 1<<bits-1)
+		}
+
+		a.envBits = bits
+		a.envCode = code
+		return nil
+	}
+}
+
+// WithDatacenter split the node bits into datacenter bits and worker bits as in the original
+// twitter implementation, e.g. WithNodeBits(10), WithDatacenter(5, dc). The high bits are set to
+// the datacenter id, the node id passed to New is the worker id in the datacenter.
+// Parse exposes both as ID.Datacenter and ID.Worker.
+func WithDatacenter(bits uint8, datacenterId uint64) Option {
+	return func(a *Algorithm) error {
+		if bits == 0 {
+			return errors.New("invalid datacenter bits")
+		}
+
+		a.datacenterBits = bits
+		a.datacenterId = datacenterId
+		return nil
+	}
+}
+
+// WithLegacyLayouts make Parse fall back to the legacy layouts during a format migration.
+// An id is decoded by the first layout it is plausible for: the environment code matches
+// and the decoded time is not in the future, so giving the old and the new layouts
+// different environment codes by WithEnvironment makes the choice unambiguous.
+func WithLegacyLayouts(legacy ...*Algorithm) Option {
+	return func(a *Algorithm) error {
+		for _, l := range legacy {
+			if l == nil {
+				return errors.New("invalid legacy layout")
+			}
+		}
+		a.legacy = append(a.legacy, legacy...)
+		return nil
+	}
+}
+
+// WithEras add era bits above the timestamp. When the 2^41 milliseconds timestamp field
+// is exhausted the algorithm rotates to the next era, whose epoch is the start time plus
+// era * 2^41 milliseconds, so the life cycle is extended by 2^bits times while Parse
+// keeps decoding the ids of older eras. The era bits share the 12 bits budget.
+func WithEras(bits uint8) Option {
+	return func(a *Algorithm) error {
+		if bits == 0 || bits > 4 {
+			return errors.New("the era bits must be between 1 and 4")
+		}
+
+		a.eraBits = bits
+		return nil
+	}
+}
+
+// WithTimestampGranularity round the embedded timestamp down to a multiple of d, e.g. an hour,
+// so externally visible ids don't leak their precise creation time. The timestamp unit stays
+// millisecond, but only the sequences of one tick are available per d, NextID waits for the
+// next tick once they are used up, so size the sequence bits accordingly.
+func WithTimestampGranularity(d time.Duration) Option {
+	return func(a *Algorithm) error {
+		if d < time.Millisecond || d%time.Millisecond != 0 {
+			return errors.New("the timestamp granularity must be a positive multiple of millisecond")
+		}
+
+		a.granularity = int64(d / time.Millisecond)
+		return nil
+	}
+}
+
+// WithTimeUnit make the timestamp count in units of d instead of milliseconds, e.g. 10ms or a second.
+// A coarser unit extends the life cycle of the algorithm by the same factor, 2^41 seconds is
+// about 69730 years, but only the sequences of one unit are available per d, NextID waits
+// for the next unit once they are used up, so size the sequence bits accordingly.
+func WithTimeUnit(d time.Duration) Option {
+	return func(a *Algorithm) error {
+		if d < time.Millisecond || d%time.Millisecond != 0 {
+			return errors.New("the time unit must be a positive multiple of millisecond")
+		}
+
+		a.unit = int64(d / time.Millisecond)
+		return nil
+	}
+}
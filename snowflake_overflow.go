@@ -0,0 +1,41 @@
+package snowflake
+
+import (
+	"errors"
+)
+
+// ErrSequenceExhausted is returned when the sequences of the current millisecond are used up
+// and the overflow policy refuses to wait.
+var ErrSequenceExhausted = errors.New("the sequences of the current millisecond are used up")
+
+// OverflowPolicy define how NextID behaves when the sequences of the current millisecond are used up.
+type OverflowPolicy int
+
+const (
+	OverflowWait            OverflowPolicy = iota // wait for the next millisecond, the default policy
+	OverflowError                                 // return ErrSequenceExhausted immediately
+	OverflowSpillNextMillis                       // borrow the sequences of the next millisecond, then wait
+)
+
+// WithOverflowPolicy set how NextID behaves when the sequences of the current millisecond
+// are used up. OverflowSpillNextMillis issues ids up to one tick ahead of the clock, so
+// bursts are absorbed without waiting, the ids never run further ahead than that.
+func WithOverflowPolicy(policy OverflowPolicy) Option {
+	return func(a *Algorithm) error {
+		if policy < OverflowWait || policy > OverflowSpillNextMillis {
+			return errors.New("invalid overflow policy")
+		}
+
+		a.overflowPolicy = policy
+		return nil
+	}
+}
+
+// spillAhead returns how far the last used tick may be ahead of the clock by spilling,
+// the tick is only considered going backwards beyond it.
+func (a *Algorithm) spillAhead() int64 {
+	if a.overflowPolicy == OverflowSpillNextMillis {
+		return a.granularity
+	}
+	return 0
+}
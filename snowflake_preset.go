@@ -0,0 +1,105 @@
+package snowflake
+
+import (
+	"errors"
+	"time"
+)
+
+// The epochs of the well-known snowflake ecosystems in unix milliseconds.
+const (
+	EpochTwitter   int64 = 1288834974657 // 2010-11-04 01:42:54.657 UTC
+	EpochDiscord   int64 = 1420070400000 // 2015-01-01 00:00:00 UTC
+	EpochInstagram int64 = 1314220021721 // 2011-08-24 21:07:01.721 UTC
+	EpochSonyflake int64 = 1409529600000 // 2014-09-01 00:00:00 UTC
+)
+
+// Preset is a named layout and epoch compatible with another snowflake implementation.
+type Preset struct {
+	Name          string
+	Epoch         time.Time
+	TimestampBits uint8         // 41 if zero
+	TimeUnit      time.Duration // millisecond if zero
+	NodeBits      uint8
+	SequenceBits  uint8
+	wide          bool // more than 53 bits, not safe for JavaScript numbers
+	zeroNode      bool // node id 0 is valid
+	sequenceHigh  bool // the sequence bits are above the node bits
+}
+
+// PresetBwmarrin matches the defaults of github.com/bwmarrin/snowflake:
+// twitter epoch, 10 node bits and 12 sequence bits, node id 0 is valid.
+// The ids use up to 63 bits, they are not safe for JavaScript numbers.
+var PresetBwmarrin = Preset{
+	Name:         "bwmarrin",
+	Epoch:        time.UnixMilli(EpochTwitter).UTC(),
+	NodeBits:     10,
+	SequenceBits: 12,
+	wide:         true,
+	zeroNode:     true,
+}
+
+// PresetSonyflake matches github.com/sony/sonyflake: the epoch 2014-09-01 UTC, 39 bit timestamp
+// in 10ms units, 8 bit sequence above 16 bit machine id, machine id 0 is valid. The life cycle
+// is about 174 years. The ids use up to 63 bits, they are not safe for JavaScript numbers.
+var PresetSonyflake = Preset{
+	Name:          "sonyflake",
+	Epoch:         time.UnixMilli(EpochSonyflake).UTC(),
+	TimestampBits: 39,
+	TimeUnit:      10 * time.Millisecond,
+	NodeBits:      16,
+	SequenceBits:  8,
+	wide:          true,
+	zeroNode:      true,
+	sequenceHigh:  true,
+}
+
+// PresetDiscord matches the discord snowflakes: the discord epoch, 10 bit node (5 bit worker and
+// 5 bit process) and 12 bit sequence, node id 0 is valid. Discord reserves 42 timestamp bits,
+// the 41 bits used here decode every discord id before 2084.
+// The ids use up to 63 bits, they are not safe for JavaScript numbers.
+var PresetDiscord = Preset{
+	Name:         "discord",
+	Epoch:        time.UnixMilli(EpochDiscord).UTC(),
+	NodeBits:     10,
+	SequenceBits: 12,
+	wide:         true,
+	zeroNode:     true,
+}
+
+// PresetInstagram matches the instagram ids: the instagram epoch, 13 bit shard id and 10 bit
+// sequence, shard id 0 is valid. Instagram reserves 41 timestamp bits, the 40 bits used here
+// keep the ids within int64 and decode every instagram id before 2046.
+// The ids use up to 63 bits, they are not safe for JavaScript numbers.
+var PresetInstagram = Preset{
+	Name:          "instagram",
+	Epoch:         time.UnixMilli(EpochInstagram).UTC(),
+	TimestampBits: 40,
+	NodeBits:      13,
+	SequenceBits:  10,
+	wide:          true,
+	zeroNode:      true,
+}
+
+// WithPreset set the layout and epoch of the algorithm to p,
+// the options after it override the preset's settings.
+func WithPreset(p Preset) Option {
+	return func(a *Algorithm) error {
+		if p.NodeBits == 0 || p.SequenceBits == 0 {
+			return errors.New("invalid preset")
+		}
+
+		a.startTime = p.Epoch
+		a.timestampBits = defaultTimestampBits
+		if p.TimestampBits != 0 {
+			a.timestampBits = p.TimestampBits
+		}
+		a.precision = time.Millisecond
+		a.unit = int64(p.TimeUnit / time.Millisecond)
+		a.nodeBits = p.NodeBits
+		a.sequenceBits = p.SequenceBits
+		a.wide = p.wide
+		a.zeroNode = p.zeroNode
+		a.sequenceHigh = p.sequenceHigh
+		return nil
+	}
+}
@@ -0,0 +1,53 @@
+package snowflake
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Pseudonymizer maps ids to keyed, deterministic pseudonyms of the same bit width, so datasets
+// can be shared with analytics vendors without exposing the real ids or the creation volumes.
+// The mapping is a permutation, different ids never share a pseudonym, and the pseudonyms
+// can't be linked back to the ids without the key. It is safe for concurrent use.
+type Pseudonymizer struct {
+	mu   sync.Mutex
+	f    *feistel
+	bits uint8
+}
+
+// NewPseudonymizer create a pseudonymizer with a secret key, the pseudonyms are in [0, 2^bits),
+// e.g. 53 for the ids of the default layout.
+func NewPseudonymizer(key []byte, bits uint8) (*Pseudonymizer, error) {
+	if len(key) == 0 {
+		return nil, errors.New("the pseudonymization key cannot be empty")
+	}
+	if bits < 2 || bits > 64 {
+		return nil, errors.New("the pseudonym bits must be between 2 and 64")
+	}
+	return &Pseudonymizer{f: newFeistel(key, bits), bits: bits}, nil
+}
+
+// Pseudonymize returns the pseudonym of id.
+func (p *Pseudonymizer) Pseudonymize(id uint64) (uint64, error) {
+	if p.bits < 64 && id >= 1<<p.bits {
+		return 0, fmt.Errorf("snowflake id %d is wider than %d bits", id, p.bits)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.f.encrypt(id), nil
+}
+
+// PseudonymizeAll returns the pseudonyms of ids in the same order.
+func (p *Pseudonymizer) PseudonymizeAll(ids []uint64) ([]uint64, error) {
+	pseudonyms := make([]uint64, len(ids))
+	for i, id := range ids {
+		v, err := p.Pseudonymize(id)
+		if err != nil {
+			return nil, err
+		}
+		pseudonyms[i] = v
+	}
+	return pseudonyms, nil
+}
@@ -0,0 +1,156 @@
+package snowflake
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+// SequenceResolver keeps the sequence state of the algorithm and decides the sequence of the
+// ids issued within a tick. The ticks are in unix milliseconds and never go backwards for
+// a resolver: a tick before the last resolved one is answered as used up.
+// The methods must be thread safe.
+type SequenceResolver interface {
+	// Resolve returns the next unused sequence in [start, limit) of the tick ms,
+	// or limit if they are used up or ms is before the last resolved tick.
+	Resolve(ms int64, start, limit uint32) (uint32, error)
+	// Last returns the last resolved tick and its last sequence.
+	Last() (ms int64, seq uint32)
+	// Restore mark the sequences of the tick ms up to seq as used, unless a later tick was resolved.
+	Restore(ms int64, seq uint32)
+}
+
+// WithSequenceResolver set the sequence resolver of the algorithm, default is a lock-free
+// resolver based on sync/atomic. Each algorithm instance needs its own resolver unless it is
+// designed to be shared, e.g. coordinated by an external store.
+func WithSequenceResolver(r SequenceResolver) Option {
+	return func(a *Algorithm) error {
+		if r == nil {
+			return errors.New("invalid sequence resolver")
+		}
+		a.sequence = r
+		return nil
+	}
+}
+
+// When you want to use the snowflake algorithm to generate unique ID, You must ensure: The sequence-number generated in the same millisecond of the same node is unique.
+// Based on this, we create this interface provide following resolver:
+// atomicSequenceResolver define as atomic sequence resolver, base on standard sync/atomic.
+type atomicSequenceResolver struct {
+	// tick和sequence打包在一个64位的字中: tick<<seqBits | seq, 一次CAS同时更新两者,
+	// 分开的两次CAS之间其他调用方可能已经进入下一个tick又回到相同的sequence(ABA)
+	// 单独分配以保证64位对齐, 共享内存模式下位于映射的文件中
+	state   *uint64
+	seqBits uint8
+	maxTick int64
+}
+
+func newAtomicSequenceResolver(state *uint64, seqBits uint8) *atomicSequenceResolver {
+	return &atomicSequenceResolver{state: state, seqBits: seqBits, maxTick: int64(^uint64(0) >> (seqBits + 1))}
+}
+
+// Resolve returns limit when the sequences below limit are used up in the millisecond.
+func (r *atomicSequenceResolver) Resolve(ms int64, start, limit uint32) (uint32, error) {
+	if ms < 0 || ms > r.maxTick {
+		return 0, ErrLifetimeExceeded
+	}
+
+	for {
+		old := atomic.LoadUint64(r.state)
+		last, lastSeq := r.unpack(old)
+		if last > ms {
+			return limit, nil
+		}
+
+		// 每毫秒从sequenceStart开始计数
+		seq := start
+		if last == ms {
+			seq = lastSeq + 1
+			if seq >= limit {
+				return limit, nil
+			}
+		}
+
+		if atomic.CompareAndSwapUint64(r.state, old, r.pack(ms, seq)) {
+			return seq, nil
+		}
+	}
+}
+
+func (r *atomicSequenceResolver) Last() (int64, uint32) {
+	return r.unpack(atomic.LoadUint64(r.state))
+}
+
+func (r *atomicSequenceResolver) Restore(ms int64, seq uint32) {
+	if ms < 0 || ms > r.maxTick {
+		return
+	}
+
+	for {
+		old := atomic.LoadUint64(r.state)
+		if last, _ := r.unpack(old); last >= ms {
+			return
+		}
+		if atomic.CompareAndSwapUint64(r.state, old, r.pack(ms, seq)) {
+			return
+		}
+	}
+}
+
+func (r *atomicSequenceResolver) pack(ms int64, seq uint32) uint64 {
+	return uint64(ms)<<r.seqBits | uint64(seq)
+}
+
+func (r *atomicSequenceResolver) unpack(state uint64) (int64, uint32) {
+	return int64(state >> r.seqBits), uint32(state & (1<<r.seqBits - 1))
+}
+
+// MutexSequenceResolver is a sequence resolver guarded by a mutex. Under heavy contention the
+// CAS loop of the default resolver keeps retrying, the mutex queues the callers instead.
+// Which one is faster depends on the cores and the workload, compare them with
+// BenchmarkAtomicResolver and BenchmarkMutexResolver on the target machine.
+type MutexSequenceResolver struct {
+	mu       sync.Mutex
+	lastTime int64
+	lastSeq  uint32
+}
+
+// NewMutexSequenceResolver create a mutex based sequence resolver, see WithSequenceResolver.
+func NewMutexSequenceResolver() *MutexSequenceResolver {
+	return &MutexSequenceResolver{}
+}
+
+func (r *MutexSequenceResolver) Resolve(ms int64, start, limit uint32) (uint32, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.lastTime > ms {
+		return limit, nil
+	}
+
+	seq := start
+	if r.lastTime == ms {
+		if r.lastSeq+1 >= limit {
+			return limit, nil
+		}
+		seq = r.lastSeq + 1
+	}
+
+	r.lastTime, r.lastSeq = ms, seq
+	return seq, nil
+}
+
+func (r *MutexSequenceResolver) Last() (int64, uint32) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.lastTime, r.lastSeq
+}
+
+func (r *MutexSequenceResolver) Restore(ms int64, seq uint32) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.lastTime < ms {
+		r.lastTime, r.lastSeq = ms, seq
+	}
+}
@@ -0,0 +1,54 @@
+package snowflake
+
+import (
+	"errors"
+	"math/bits"
+	"sync/atomic"
+)
+
+// ShardedGenerator runs several algorithm instances and round-robins NextID across them,
+// eliminating the contention on a single sequence state on many-core machines.
+// Each shard uses a distinct node id: the node id passed to NewSharded followed by the
+// shard index in the low-order node bits.
+type ShardedGenerator struct {
+	shards []*Algorithm
+	next   atomic.Uint64
+}
+
+// NewSharded create a ShardedGenerator with shards instances, shards must be a power of two.
+// The node bits must hold the node id and log2(shards) shard bits, the options are applied to
+// every shard, so the options claiming a node id or a state file, e.g. WithNodeIDProvider and
+// WithSharedState, are not supported.
+func NewSharded(nodeId uint64, shards int, options ...Option) (*ShardedGenerator, error) {
+	if shards < 1 || shards&(shards-1) != 0 {
+		return nil, errors.New("the number of shards must be a power of two")
+	}
+
+	shardBits := bits.TrailingZeros(uint(shards))
+	s := &ShardedGenerator{shards: make([]*Algorithm, shards)}
+	for i := range s.shards {
+		a, err := New(nodeId<<shardBits|uint64(i), options...)
+		if err != nil {
+			return nil, err
+		}
+		s.shards[i] = a
+	}
+	return s, nil
+}
+
+// NextID generate snowflake id on the next shard.
+// This function is thread safe.
+func (s *ShardedGenerator) NextID() (uint64, error) {
+	i := s.next.Add(1) % uint64(len(s.shards))
+	return s.shards[i].NextID()
+}
+
+// Parse snowflake id to ID struct, the node includes the shard bits.
+func (s *ShardedGenerator) Parse(id uint64) ID {
+	return s.shards[0].Parse(id)
+}
+
+// Shards returns the algorithm instances of the shards.
+func (s *ShardedGenerator) Shards() []*Algorithm {
+	return append([]*Algorithm(nil), s.shards...)
+}
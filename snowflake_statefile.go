@@ -0,0 +1,162 @@
+package snowflake
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// stateFileLease is how far ahead of the issued ids the state file is persisted,
+// the file is written at most once per lease.
+const stateFileLease = time.Second
+
+// stateFileClosed marks the state file written by Close, it holds the exact last issued tick
+// instead of a lease.
+const stateFileClosed = "closed"
+
+// stateFile persists a tick the issued ids never go beyond, the millisecond by default.
+type stateFile struct {
+	path     string
+	restored int64 // the tick read on start
+	closed   bool  // the restored tick is the exact last issued one, not a lease
+	ahead    int64 // stateFileLease in ticks
+	// 从租约恢复的tick, 时钟落后它不超过一个租约时只需等待, 不视为时钟回拨
+	leaseTick int64
+
+	mu    sync.Mutex
+	lease atomic.Int64 // ids up to the millisecond can be issued without writing the file
+}
+
+// WithStateFile persist the highest millisecond the algorithm may have issued ids at in the file
+// at path, so a restart onto a clock behind it never reissues ids: NextID waits until the clock
+// catches up, or returns ErrClockBackwards with the ClockBackwardsError policy.
+// Unlike WithMmapState it works on every platform: the file is rewritten and synced once
+// per second of ids, recording a lease one second ahead of them. Call Close on shutdown to
+// record the exact last issued millisecond instead, otherwise the restart waits up to a second
+// for the lease to pass. The lease wait is not a clock regression, it never returns
+// ErrClockBackwards and is not reported as clock backwards.
+func WithStateFile(path string) Option {
+	return func(a *Algorithm) error {
+		restored, closed, err := readStateFile(path)
+		if err != nil {
+			return err
+		}
+
+		a.stateFile = &stateFile{path: path, restored: restored, closed: closed}
+		return nil
+	}
+}
+
+// Close persist the exact last issued tick to the state file of WithStateFile, so a clean
+// restart does not wait for the lease. It does nothing without a state file. The algorithm
+// can still be used after Close, the next id persists a new lease.
+func (a *Algorithm) Close() error {
+	if a.stateFile == nil {
+		return nil
+	}
+	return a.stateFile.close(a.sequence)
+}
+
+// leaseWait check whether the clock at now is only behind the lease restored from the state file
+// rather than the last issued tick, e.g. right after a restart.
+func (a *Algorithm) leaseWait(now, last int64) bool {
+	return a.stateFile != nil && last == a.stateFile.leaseTick && last-now <= a.stateFile.ahead
+}
+
+// restore mark the restored ticks as used, a lease is also remembered for leaseWait.
+func (s *stateFile) restore(a *Algorithm) {
+	s.ahead = int64(stateFileLease / a.precision)
+	if !s.closed && s.restored > 0 {
+		s.leaseTick = s.restored
+	}
+	a.restoreMillis(s.restored)
+}
+
+// close write the last resolved tick of r to the file.
+func (s *stateFile) close(r SequenceResolver) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// 先让新的id重新写租约, 再读取最后的tick, 之后发放的id不会早于写入的tick
+	lease := s.lease.Swap(0)
+	last, _ := r.Last()
+	if last < s.restored {
+		last = s.restored
+	}
+
+	if err := writeStateFile(s.path, strconv.FormatInt(last, 10)+" "+stateFileClosed); err != nil {
+		s.lease.Store(lease)
+		return err
+	}
+	return nil
+}
+
+// reserve make sure the persisted millisecond is not before ms.
+func (s *stateFile) reserve(ms int64) error {
+	if ms <= s.lease.Load() {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if ms <= s.lease.Load() {
+		return nil
+	}
+
+	lease := ms + s.ahead
+	if err := writeStateFile(s.path, strconv.FormatInt(lease, 10)); err != nil {
+		return err
+	}
+	s.lease.Store(lease)
+	return nil
+}
+
+// readStateFile read the persisted millisecond, it is 0 if the file does not exist.
+// The file is "<lease>", or "<last issued> closed" written by Close.
+func readStateFile(path string) (int64, bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return 0, false, nil
+		}
+		return 0, false, err
+	}
+
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 || len(fields) > 2 || (len(fields) == 2 && fields[1] != stateFileClosed) {
+		return 0, false, fmt.Errorf("invalid state file %s", path)
+	}
+
+	ms, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return 0, false, fmt.Errorf("invalid state file %s: %w", path, err)
+	}
+	return ms, len(fields) == 2, nil
+}
+
+// writeStateFile replace the state file atomically with the content.
+func writeStateFile(path string, content string) error {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err = f.WriteString(content + "\n"); err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
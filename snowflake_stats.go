@@ -0,0 +1,54 @@
+package snowflake
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Stats is the generation statistics of an algorithm instance since New, e.g. to decide
+// whether the sequence bits are sized right.
+type Stats struct {
+	Issued            uint64        // the ids issued by NextID and its variants
+	ExhaustedTicks    uint64        // the ticks whose sequences were used up
+	WaitTime          time.Duration // the total time NextID waited for the next tick
+	Uptime            time.Duration // the time since New
+	IDsPerMillisecond float64       // the average ids issued per millisecond of uptime
+}
+
+// stats is the counters of Stats.
+type stats struct {
+	created       time.Time
+	issued        atomic.Uint64
+	exhausted     atomic.Uint64
+	lastExhausted atomic.Int64 // the last tick counted as exhausted
+	wait          atomic.Int64 // nanoseconds
+}
+
+// Stats returns the generation statistics since New.
+// This function is thread safe.
+func (a *Algorithm) Stats() Stats {
+	s := Stats{
+		Issued:         a.stats.issued.Load(),
+		ExhaustedTicks: a.stats.exhausted.Load(),
+		WaitTime:       time.Duration(a.stats.wait.Load()),
+		Uptime:         time.Since(a.stats.created),
+	}
+	if ms := s.Uptime.Seconds() * 1000; ms > 0 {
+		s.IDsPerMillisecond = float64(s.Issued) / ms
+	}
+	return s
+}
+
+// exhaust count the tick c as exhausted, once per tick.
+func (s *stats) exhaust(c int64) {
+	for {
+		last := s.lastExhausted.Load()
+		if c <= last {
+			return
+		}
+		if s.lastExhausted.CompareAndSwap(last, c) {
+			s.exhausted.Add(1)
+			return
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package snowflake
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Stream is a stream of ids continuously generated in a background goroutine, see OpenStream.
+type Stream struct {
+	// C delivers the ids, it is closed once the stream stops.
+	C <-chan uint64
+
+	mu  sync.Mutex
+	err error
+}
+
+// Stream continuously generates ids into a channel with buffer capacity until ctx is cancelled,
+// for pipelines pulling ids from a channel, e.g. for id := range a.Stream(ctx, 64).
+// It is OpenStream without the error report, see OpenStream for when the channel is closed.
+func (a *Algorithm) Stream(ctx context.Context, buffer int) <-chan uint64 {
+	return a.OpenStream(ctx, buffer).C
+}
+
+// OpenStream continuously generates ids into the channel C of the stream with buffer capacity
+// until ctx is cancelled. The errors which can never recover, i.e. ErrLifetimeExceeded,
+// ErrMachineIDRejected and ErrTimelineExhausted in replay mode, stop the stream and are
+// reported by Err. Any other error, e.g. ErrClockBackwards or a network error of the sequence
+// resolver, is retried after a while.
+func (a *Algorithm) OpenStream(ctx context.Context, buffer int) *Stream {
+	if buffer < 0 {
+		buffer = 0
+	}
+
+	ch := make(chan uint64, buffer)
+	s := &Stream{C: ch}
+	go func() {
+		defer close(ch)
+
+		for {
+			id, err := a.NextID()
+			if err != nil {
+				if permanentError(err) {
+					s.mu.Lock()
+					s.err = err
+					s.mu.Unlock()
+					return
+				}
+
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(bufferRetryInterval):
+				}
+				continue
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case ch <- id:
+			}
+		}
+	}()
+	return s
+}
+
+// Err returns the error which stopped the stream, it is nil while the stream is running or
+// if it was stopped by ctx.
+func (s *Stream) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// permanentError check whether NextID can never succeed again after it returned err.
+func permanentError(err error) bool {
+	return errors.Is(err, ErrLifetimeExceeded) || errors.Is(err, ErrMachineIDRejected) || errors.Is(err, ErrTimelineExhausted)
+}
@@ -0,0 +1,63 @@
+package snowflake
+
+import (
+	"fmt"
+	"strconv"
+	"text/template"
+)
+
+// FuncMap returns the template functions decoding ids with the algorithm:
+//
+//	snowflakeTime  the generation time of the id
+//	snowflakeShort the base62 form of the id
+//	snowflakeNode  the node of the id
+//
+// The functions accept ids as integers or decimal strings. As html/template.FuncMap
+// is an alias of text/template.FuncMap, it can be used by both packages.
+func (a *Algorithm) FuncMap() template.FuncMap {
+	return template.FuncMap{
+		"snowflakeTime": func(v any) (string, error) {
+			id, err := toUint64(v)
+			if err != nil {
+				return "", err
+			}
+			return a.Parse(id).GetTime().Format("2006-01-02 15:04:05.000"), nil
+		},
+		"snowflakeShort": func(v any) (string, error) {
+			id, err := toUint64(v)
+			if err != nil {
+				return "", err
+			}
+			return formatBase(id, 0, base62Alphabet), nil
+		},
+		"snowflakeNode": func(v any) (uint64, error) {
+			id, err := toUint64(v)
+			if err != nil {
+				return 0, err
+			}
+			return a.Parse(id).Node, nil
+		},
+	}
+}
+
+func toUint64(v any) (uint64, error) {
+	switch n := v.(type) {
+	case uint64:
+		return n, nil
+	case int64:
+		if n >= 0 {
+			return uint64(n), nil
+		}
+	case int:
+		if n >= 0 {
+			return uint64(n), nil
+		}
+	case uint:
+		return uint64(n), nil
+	case ID:
+		return n.id, nil
+	case string:
+		return strconv.ParseUint(n, 10, 64)
+	}
+	return 0, fmt.Errorf("invalid snowflake id: %v", v)
+}
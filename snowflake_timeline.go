@@ -0,0 +1,67 @@
+package snowflake
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrTimelineExhausted is returned by NextID in replay mode when all the decisions are replayed.
+var ErrTimelineExhausted = errors.New("the replayed timeline is exhausted")
+
+// Decision is a (timestamp, sequence) decision made by the generator for one id.
+type Decision struct {
+	Millis   int64  `json:"millis"` // unix millisecond, or microsecond with the microsecond precision
+	Sequence uint32 `json:"sequence"`
+}
+
+// Timeline is an ordered list of decisions, it is recorded by WithRecorder and
+// replayed by WithReplay. It is safe for concurrent use.
+//
+// Decisions of concurrent callers are recorded in the order they are made, so a
+// replay reproduces the recorded ids exactly, but can't reproduce which caller got which id.
+type Timeline struct {
+	mu        sync.Mutex
+	decisions []Decision
+	pos       int
+}
+
+// NewTimeline create a timeline, e.g. with the decisions loaded from an incident record.
+func NewTimeline(decisions ...Decision) *Timeline {
+	return &Timeline{decisions: decisions}
+}
+
+// Decisions returns a copy of the decisions in the timeline.
+func (t *Timeline) Decisions() []Decision {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]Decision(nil), t.decisions...)
+}
+
+func (t *Timeline) record(ms int64, seq uint32) {
+	t.mu.Lock()
+	t.decisions = append(t.decisions, Decision{Millis: ms, Sequence: seq})
+	t.mu.Unlock()
+}
+
+func (t *Timeline) next() (int64, uint32, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.pos >= len(t.decisions) {
+		return 0, 0, ErrTimelineExhausted
+	}
+	d := t.decisions[t.pos]
+	t.pos++
+	return d.Millis, d.Sequence, nil
+}
+
+func (t *Timeline) peek() (int64, uint32, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.pos >= len(t.decisions) {
+		return 0, 0, ErrTimelineExhausted
+	}
+	d := t.decisions[t.pos]
+	return d.Millis, d.Sequence, nil
+}
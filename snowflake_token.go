@@ -0,0 +1,154 @@
+package snowflake
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+const (
+	tokenAlphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ1234567890"
+	tokenSeps     = "cfhistuCFHISTU"
+)
+
+// TokenCodec converts snowflake ids to short, salted, alphanumeric public tokens and back,
+// so APIs can expose compact opaque identifiers while keeping the snowflake ids internally.
+// The tokens are compatible with the hashids of the same salt and minimum length encoding
+// a single number. They only hide the ids from casual inspection, use WithObfuscation
+// or a Pseudonymizer if the ids must not be recoverable without a key.
+// It is safe for concurrent use.
+type TokenCodec struct {
+	salt      string
+	minLength int
+	alphabet  string
+	seps      string
+	guards    string
+}
+
+// NewTokenCodec create a token codec with the salt, the tokens are padded to at least minLength
+// characters.
+func NewTokenCodec(salt string, minLength int) (*TokenCodec, error) {
+	if minLength < 0 {
+		return nil, errors.New("the minimum token length cannot be negative")
+	}
+
+	alphabet := []byte(tokenAlphabet)
+	var seps []byte
+	for i := 0; i < len(tokenSeps); i++ {
+		if j := strings.IndexByte(string(alphabet), tokenSeps[i]); j >= 0 {
+			seps = append(seps, tokenSeps[i])
+			alphabet = append(alphabet[:j], alphabet[j+1:]...)
+		}
+	}
+	shuffle(seps, salt)
+
+	// 分隔符和字母表的比例保持在1:3.5
+	if len(seps) == 0 || float64(len(alphabet))/float64(len(seps)) > 3.5 {
+		n := int(math.Ceil(float64(len(alphabet)) / 3.5))
+		if n == 1 {
+			n++
+		}
+		if n > len(seps) {
+			diff := n - len(seps)
+			seps = append(seps, alphabet[:diff]...)
+			alphabet = alphabet[diff:]
+		} else {
+			seps = seps[:n]
+		}
+	}
+	shuffle(alphabet, salt)
+
+	guardCount := int(math.Ceil(float64(len(alphabet)) / 12))
+	guards := alphabet[:guardCount]
+	alphabet = alphabet[guardCount:]
+
+	return &TokenCodec{
+		salt:      salt,
+		minLength: minLength,
+		alphabet:  string(alphabet),
+		seps:      string(seps),
+		guards:    string(guards),
+	}, nil
+}
+
+// Encode returns the token of id.
+func (c *TokenCodec) Encode(id uint64) string {
+	alphabet := []byte(c.alphabet)
+	numbersHash := id % 100
+	lottery := alphabet[numbersHash%uint64(len(alphabet))]
+
+	buffer := append([]byte{lottery}, c.salt...)
+	buffer = append(buffer, alphabet...)
+	shuffle(alphabet, string(buffer[:len(alphabet)]))
+
+	token := append([]byte{lottery}, formatBase(id, 0, string(alphabet))...)
+
+	// 长度不足时先在两端补guard, 再用打乱的字母表补齐
+	if len(token) < c.minLength {
+		guard := c.guards[(numbersHash+uint64(token[0]))%uint64(len(c.guards))]
+		token = append([]byte{guard}, token...)
+
+		if len(token) < c.minLength {
+			guard = c.guards[(numbersHash+uint64(token[2]))%uint64(len(c.guards))]
+			token = append(token, guard)
+		}
+	}
+
+	half := len(alphabet) / 2
+	for len(token) < c.minLength {
+		shuffle(alphabet, string(alphabet))
+		padded := append([]byte(nil), alphabet[half:]...)
+		padded = append(padded, token...)
+		token = append(padded, alphabet[:half]...)
+
+		if excess := len(token) - c.minLength; excess > 0 {
+			token = token[excess/2 : excess/2+c.minLength]
+		}
+	}
+	return string(token)
+}
+
+// Decode returns the id of the token produced by Encode with the same salt and minimum length.
+func (c *TokenCodec) Decode(token string) (uint64, error) {
+	// 去掉两端的guard
+	parts := strings.Split(strings.Map(func(r rune) rune {
+		if strings.ContainsRune(c.guards, r) {
+			return ' '
+		}
+		return r
+	}, token), " ")
+	body := parts[0]
+	if len(parts) == 2 || len(parts) == 3 {
+		body = parts[1]
+	}
+	if len(body) < 2 {
+		return 0, fmt.Errorf("invalid snowflake token %q", token)
+	}
+
+	alphabet := []byte(c.alphabet)
+	buffer := append([]byte{body[0]}, c.salt...)
+	buffer = append(buffer, alphabet...)
+	shuffle(alphabet, string(buffer[:len(alphabet)]))
+
+	id, err := parseBase(body[1:], string(alphabet))
+	if err != nil || c.Encode(id) != token {
+		return 0, fmt.Errorf("invalid snowflake token %q", token)
+	}
+	return id, nil
+}
+
+// shuffle permute alphabet in place by the salt, the same salt always gives the same permutation.
+func shuffle(alphabet []byte, salt string) {
+	if salt == "" {
+		return
+	}
+
+	for i, v, p := len(alphabet)-1, 0, 0; i > 0; i, v = i-1, v+1 {
+		v %= len(salt)
+		n := int(salt[v])
+		p += n
+		j := (n + v + p) % i
+		alphabet[i], alphabet[j] = alphabet[j], alphabet[i]
+	}
+}
@@ -0,0 +1,76 @@
+package snowflake
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"math/bits"
+	"time"
+)
+
+// NextUUIDv7 generate a RFC 9562 UUIDv7 backed by the same clock and sequence state as NextID,
+// for the schemas requiring 128-bit UUID columns. The 48 bit unix millisecond is followed by
+// the sequence and the node id in the counter bits, preceded by the sub-millisecond ticks with
+// the microsecond precision, the remaining bits are random, so the
+// UUIDs are time-ordered and unique across nodes. The result converts directly to the 16 bytes
+// UUID types, e.g. uuid.UUID(u) of github.com/google/uuid.
+// This function is thread safe.
+func (a *Algorithm) NextUUIDv7() ([16]byte, error) {
+	ticks, seq, err := a.resolve(a.maxSequence - a.priorityReserve)
+	if err != nil {
+		return [16]byte{}, err
+	}
+	perMs := int64(time.Millisecond / a.precision)
+	ms, sub := ticks/perMs, uint64(ticks%perMs)
+	subBits := uint8(bits.Len64(uint64(perMs - 1)))
+
+	var rnd [16]byte
+	if _, err = rand.Read(rnd[:]); err != nil {
+		return [16]byte{}, err
+	}
+	r1 := binary.BigEndian.Uint64(rnd[:8])
+	r2 := binary.BigEndian.Uint64(rnd[8:])
+
+	// 74位的rand_a(12位)和rand_b(62位): 毫秒内的tick, sequence和node在高位, 其余为随机数
+	k := subBits + a.sequenceBits + a.nodeBits
+	counter := (sub<<a.sequenceBits|uint64(seq))<<a.nodeBits | a.nodeId
+	var randA, randB uint64
+	if k <= 12 {
+		randA = counter<<(12-k) | r1&(1<<(12-k)-1)
+		randB = r2 & (1<<62 - 1)
+	} else {
+		m := k - 12
+		randA = counter >> m
+		randB = (counter&(1<<m-1))<<(62-m) | r2&(1<<(62-m)-1)
+	}
+
+	var u [16]byte
+	binary.BigEndian.PutUint64(u[:8], uint64(ms)&(1<<48-1)<<16|0x7<<12|randA)
+	binary.BigEndian.PutUint64(u[8:], 0b10<<62|randB)
+	return u, nil
+}
+
+// UUID embeds the id losslessly into a RFC 9562 version 8 (custom) UUID, for the systems with
+// UUID-only columns. The id bits fill the UUID from the most significant bit around the version
+// and variant bits and the rest is zero, so the UUIDs sort like the ids.
+func (i ID) UUID() [16]byte {
+	var u [16]byte
+	binary.BigEndian.PutUint64(u[:8], i.id>>16<<16|0x8<<12|i.id>>4&0xfff)
+	u[8] = 0b10<<6 | byte(i.id&0xf)<<2
+	return u
+}
+
+// FromUUID returns the raw id embedded by ID.UUID.
+func FromUUID(u [16]byte) (uint64, error) {
+	if u[6]>>4 != 0x8 || u[8]>>6 != 0b10 || u[8]&0b11 != 0 {
+		return 0, errors.New("invalid snowflake uuid")
+	}
+	for _, b := range u[9:] {
+		if b != 0 {
+			return 0, errors.New("invalid snowflake uuid")
+		}
+	}
+
+	hi := binary.BigEndian.Uint64(u[:8])
+	return hi>>16<<16 | hi&0xfff<<4 | uint64(u[8]>>2&0xf), nil
+}
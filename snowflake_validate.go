@@ -0,0 +1,59 @@
+package snowflake
+
+import (
+	"errors"
+	"fmt"
+)
+
+// WithKnownNodes set the node ids Validate accepts, e.g. all the node ids of a deployment.
+// Without it Validate accepts any valid node id.
+func WithKnownNodes(nodeIds ...uint64) Option {
+	return func(a *Algorithm) error {
+		if len(nodeIds) == 0 {
+			return errors.New("invalid known nodes")
+		}
+
+		a.knownNodes = make(map[uint64]struct{}, len(nodeIds))
+		for _, nodeId := range nodeIds {
+			a.knownNodes[nodeId] = struct{}{}
+		}
+		return nil
+	}
+}
+
+// Validate check whether id could have been generated by the algorithm, e.g. to reject
+// forged or corrupted ids at API boundaries: it has no bits beyond the layout, its time is
+// not in the future, its environment and node are valid, its sequence is in the range NextID
+// uses and it is not rejected by the decimal filters.
+func (a *Algorithm) Validate(id uint64) error {
+	if a.deobfuscate(id)>>a.timestampMoveLength > a.maxElapsed {
+		return fmt.Errorf("invalid snowflake id %d: bits beyond the layout", id)
+	}
+
+	parsed := a.parse(id)
+	if parsed.GetTime().After(a.clock.Now().Add(plausibleClockSkew)) {
+		return fmt.Errorf("invalid snowflake id %d: time in the future", id)
+	}
+
+	if parsed.Environment != a.envCode {
+		return fmt.Errorf("invalid snowflake id %d: environment %d", id, parsed.Environment)
+	}
+
+	if a.knownNodes != nil {
+		if _, exists := a.knownNodes[parsed.Node]; !exists {
+			return fmt.Errorf("invalid snowflake id %d: unknown node %d", id, parsed.Node)
+		}
+	} else if parsed.Worker == 0 && !a.zeroNode {
+		return fmt.Errorf("invalid snowflake id %d: invalid node", id)
+	}
+
+	// 严格单调模式下successor可能用到最大的sequence
+	if parsed.Sequence < uint64(a.sequenceStart) || (parsed.Sequence == uint64(a.maxSequence) && !a.strictMonotonic) {
+		return fmt.Errorf("invalid snowflake id %d: sequence %d out of range", id, parsed.Sequence)
+	}
+
+	if a.skipped(id) {
+		return fmt.Errorf("invalid snowflake id %d: rejected by the decimal filters", id)
+	}
+	return nil
+}
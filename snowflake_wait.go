@@ -0,0 +1,49 @@
+package snowflake
+
+import (
+	"errors"
+	"time"
+)
+
+// WaitStrategy define how NextID waits for the next millisecond when the sequences are used up.
+type WaitStrategy int
+
+const (
+	WaitSleep   WaitStrategy = iota // sleep until the next millisecond, the default strategy
+	WaitSpin                        // busy-wait, the lowest latency but burns a full core
+	WaitBackoff                     // sleep with exponential backoff starting from 10µs
+)
+
+const (
+	minBackoff = 10 * time.Microsecond
+	maxBackoff = time.Millisecond
+)
+
+// WithWaitStrategy set how NextID waits for the next millisecond when the sequences are used up.
+func WithWaitStrategy(strategy WaitStrategy) Option {
+	return func(a *Algorithm) error {
+		if strategy < WaitSleep || strategy > WaitBackoff {
+			return errors.New("invalid wait strategy")
+		}
+		a.waitStrategy = strategy
+		return nil
+	}
+}
+
+// waitForNextMillis wait until the millisecond after last with the wait strategy of the algorithm.
+func (a *Algorithm) waitForNextMillis(last int64) int64 {
+	now := a.millis()
+	backoff := minBackoff
+	for now <= last {
+		switch a.waitStrategy {
+		case WaitSpin:
+		case WaitBackoff:
+			time.Sleep(backoff)
+			backoff = min(backoff*2, maxBackoff)
+		default:
+			time.Sleep(time.Duration(last+1-now) * a.precision)
+		}
+		now = a.millis()
+	}
+	return now
+}
@@ -0,0 +1,84 @@
+// Package snowflaketest provides deterministic snowflake generators for unit tests, so the
+// stored ids asserted by the tests are the same on every run.
+//
+//	func TestCreateOrder(t *testing.T) {
+//		generator := snowflaketest.New(t)
+//		id := generator.MustNextID() // always the same id
+//		...
+//	}
+package snowflaketest
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hdget/snowflake"
+)
+
+const (
+	// NodeID is the node id of the generators created by New.
+	NodeID = 1
+	// Step is how far the clock of the generators created by New advances per reading.
+	Step = time.Millisecond
+)
+
+// Start is the time the clock of the generators created by New starts at.
+var Start = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// Clock is a fake clock advancing by a fixed step each time it is read, so the generation
+// never waits on the real time and a sequence of readings is reproducible.
+// It is safe for concurrent use.
+type Clock struct {
+	mu   sync.Mutex
+	now  time.Time
+	step time.Duration
+}
+
+// NewClock create a fake clock starting at start, a zero step freezes it.
+func NewClock(start time.Time, step time.Duration) *Clock {
+	return &Clock{now: start, step: step}
+}
+
+// Now returns the current time of the clock and advances it by the step.
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now
+	c.now = c.now.Add(c.step)
+	return now
+}
+
+// Set set the current time of the clock.
+func (c *Clock) Set(t time.Time) {
+	c.mu.Lock()
+	c.now = t
+	c.mu.Unlock()
+}
+
+// Advance move the clock forward by d, or backwards if d is negative.
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	c.mu.Unlock()
+}
+
+// New create a generator with node NodeID and a fake clock starting at Start advancing by Step,
+// so its ids are strictly increasing and the same on every run. Every reading of the clock
+// advances it, e.g. by NextID, ParseStrict and Validate, the ids only depend on the calls made.
+// The options are applied after the defaults, e.g. snowflake.WithClock overrides the clock.
+// The test fails if the generator can not be created.
+func New(tb testing.TB, options ...snowflake.Option) *snowflake.Algorithm {
+	tb.Helper()
+
+	defaults := []snowflake.Option{
+		snowflake.WithClock(NewClock(Start, Step)),
+		snowflake.WithStrictMonotonic(),
+	}
+	a, err := snowflake.New(NodeID, append(defaults, options...)...)
+	if err != nil {
+		tb.Fatalf("snowflaketest: %v", err)
+	}
+	return a
+}
@@ -0,0 +1,132 @@
+// Package stress runs a uniqueness stress test against the snowflake generators: several
+// goroutines across several simulated nodes generate ids for a wall-clock duration, and every
+// id is stored in a concurrent set, so a duplicate fails the run.
+//
+//	report, err := stress.Run(stress.Config{Goroutines: 64, Nodes: 4, Duration: time.Minute})
+//
+// The set keeps every id in memory, about 40 bytes per id.
+package stress
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hdget/snowflake"
+)
+
+// setShards is the number of the shards of the id set, to reduce the lock contention.
+const setShards = 256
+
+// ErrDuplicate is returned by Run when an id was generated twice.
+var ErrDuplicate = errors.New("duplicate snowflake id")
+
+// Config is the stress test configuration.
+type Config struct {
+	Goroutines int                // the goroutines generating ids, default 8
+	Nodes      int                // the simulated nodes, each is an algorithm instance with node id 1..Nodes, default 1
+	Duration   time.Duration      // how long the ids are generated, default 10 seconds
+	Options    []snowflake.Option // the options of the algorithm instances
+}
+
+// Report is the result of a stress test.
+type Report struct {
+	IDs     uint64        // the generated ids
+	Errors  uint64        // the NextID calls returned an error
+	Elapsed time.Duration // the wall-clock duration of the test
+}
+
+// Run run the stress test, it returns an error wrapping ErrDuplicate on the first duplicate id.
+// The goroutines are assigned to the nodes round-robin.
+func Run(cfg Config) (Report, error) {
+	if cfg.Goroutines <= 0 {
+		cfg.Goroutines = 8
+	}
+	if cfg.Nodes <= 0 {
+		cfg.Nodes = 1
+	}
+	if cfg.Duration <= 0 {
+		cfg.Duration = 10 * time.Second
+	}
+
+	nodes := make([]*snowflake.Algorithm, cfg.Nodes)
+	for i := range nodes {
+		a, err := snowflake.New(uint64(i+1), cfg.Options...)
+		if err != nil {
+			return Report{}, fmt.Errorf("create node %d: %w", i+1, err)
+		}
+		nodes[i] = a
+	}
+
+	var (
+		set       idSet
+		ids, errs atomic.Uint64
+		stop      atomic.Bool
+		dupOnce   sync.Once
+		dupErr    error
+		wg        sync.WaitGroup
+	)
+	set.init()
+
+	start := time.Now()
+	timer := time.AfterFunc(cfg.Duration, func() { stop.Store(true) })
+	defer timer.Stop()
+
+	for g := 0; g < cfg.Goroutines; g++ {
+		wg.Add(1)
+		go func(a *snowflake.Algorithm, node int) {
+			defer wg.Done()
+
+			for !stop.Load() {
+				id, err := a.NextID()
+				if err != nil {
+					errs.Add(1)
+					continue
+				}
+
+				if !set.add(id) {
+					dupOnce.Do(func() {
+						dupErr = fmt.Errorf("%w %d on node %d after %d ids", ErrDuplicate, id, node, ids.Load())
+					})
+					stop.Store(true)
+					return
+				}
+				ids.Add(1)
+			}
+		}(nodes[g%cfg.Nodes], g%cfg.Nodes+1)
+	}
+	wg.Wait()
+
+	report := Report{IDs: ids.Load(), Errors: errs.Load(), Elapsed: time.Since(start)}
+	return report, dupErr
+}
+
+// idSet is a concurrent set of ids sharded by the low bits.
+type idSet struct {
+	shards [setShards]struct {
+		mu  sync.Mutex
+		ids map[uint64]struct{}
+	}
+}
+
+func (s *idSet) init() {
+	for i := range s.shards {
+		s.shards[i].ids = make(map[uint64]struct{})
+	}
+}
+
+// add add id to the set, it returns false if id is already in the set.
+func (s *idSet) add(id uint64) bool {
+	// 低位为sequence和node, 分布较均匀
+	shard := &s.shards[id%setShards]
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	if _, exists := shard.ids[id]; exists {
+		return false
+	}
+	shard.ids[id] = struct{}{}
+	return true
+}
@@ -0,0 +1,65 @@
+// Package workflowid generates snowflake ids inside deterministic workflows, e.g. Temporal or Cadence.
+//
+// Workflow code is replayed to rebuild its state, calling a generator directly would mint
+// a different id on every replay. The Generator here runs the generation through a side
+// effect boundary, so the id is recorded in the workflow history on first execution and the
+// recorded id is returned on replay. With Temporal it is wired like:
+//
+//	ids := workflowid.New(generator, func(fn func() workflowid.Record) (workflowid.Record, error) {
+//		var rec workflowid.Record
+//		err := workflow.SideEffect(ctx, func(workflow.Context) any { return fn() }).Get(&rec)
+//		return rec, err
+//	})
+//	id, err := ids.NextID()
+//
+// Running the generation in an activity works as well, the activity result is recorded the same way.
+package workflowid
+
+import (
+	"errors"
+)
+
+// Generator generates the ids, *snowflake.Algorithm implements it.
+type Generator interface {
+	NextID() (uint64, error)
+}
+
+// Record is the result of one generation recorded in the workflow history.
+type Record struct {
+	ID    uint64 `json:"id"`
+	Error string `json:"error,omitempty"`
+}
+
+// SideEffect executes fn once and records its result in the workflow history,
+// on replay it returns the recorded result without executing fn.
+type SideEffect func(fn func() Record) (Record, error)
+
+// Deterministic is a replay-stable generator for workflow code.
+type Deterministic struct {
+	gen        Generator
+	sideEffect SideEffect
+}
+
+// New create a replay-stable generator generating ids by gen through sideEffect.
+func New(gen Generator, sideEffect SideEffect) *Deterministic {
+	return &Deterministic{gen: gen, sideEffect: sideEffect}
+}
+
+// NextID returns the id recorded for this point of the workflow, generating it on first execution.
+func (d *Deterministic) NextID() (uint64, error) {
+	rec, err := d.sideEffect(func() Record {
+		id, err := d.gen.NextID()
+		if err != nil {
+			return Record{Error: err.Error()}
+		}
+		return Record{ID: id}
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if rec.Error != "" {
+		return 0, errors.New(rec.Error)
+	}
+	return rec.ID, nil
+}